@@ -14,30 +14,39 @@ var (
 	MongoClient *mongo.Client
 )
 
-// ConnectMongo connects to a MongoDB database using the provided configuration.
-func ConnectMongo(config configs.MongoConfig) {
+// NewMongoClient connects to the MongoDB server of the provided configuration.
+func NewMongoClient(config configs.MongoConfig) (*mongo.Client, error) {
 	host := config.Host
 	port := config.Port
 	user := config.User
 	password := config.Password
-	database := config.Database
 
 	clientOptions := options.Client().ApplyURI(
 		fmt.Sprintf("mongodb://%s:%s@%s:%d", user, password, host, port),
 	)
 	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
-		log.Fatalf("failed to connect to mongodb: %v", err)
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
 	}
 
 	// Check the connection
 	err = client.Ping(context.TODO(), nil)
 	if err != nil {
-		log.Fatalf("failed to ping to mongodb: %v", err)
+		_ = client.Disconnect(context.TODO())
+		return nil, fmt.Errorf("failed to ping to mongodb: %w", err)
+	}
+	return client, nil
+}
+
+// ConnectMongo connects to a MongoDB database using the provided configuration.
+func ConnectMongo(config configs.MongoConfig) {
+	client, err := NewMongoClient(config)
+	if err != nil {
+		log.Fatal(err)
 	}
 	log.Println("Connected to MongoDB!")
 
 	// if the database does not exist, MongoDB create it
-	DB = client.Database(database)
+	DB = client.Database(config.Database)
 	MongoClient = client
 }
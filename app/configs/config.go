@@ -21,9 +21,37 @@ type MCPClient struct {
 	SSE     bool   `mapstructure:"sse" json:"sse" yaml:"sse"`
 }
 
+type EntityIdConfig struct {
+	Prefix     string `mapstructure:"prefix" json:"prefix" yaml:"prefix"`
+	Template   string `mapstructure:"template" json:"template" yaml:"template"`
+	Width      int    `mapstructure:"width" json:"width" yaml:"width"`
+	Separator  string `mapstructure:"separator" json:"separator" yaml:"separator"`
+	DateFormat string `mapstructure:"date_format" json:"date_format" yaml:"date_format"`
+	Start      int64  `mapstructure:"start" json:"start" yaml:"start"`
+	Strategy   string `mapstructure:"strategy" json:"strategy" yaml:"strategy"`
+}
+
+type IdGeneratorConfig struct {
+	CountersCollection string                    `mapstructure:"counters_collection" json:"counters_collection" yaml:"counters_collection"`
+	Entities           map[string]EntityIdConfig `mapstructure:"entities" json:"entities" yaml:"entities"`
+	NodeId             int64                     `mapstructure:"node_id" json:"node_id" yaml:"node_id"`
+}
+
+type ExportConfig struct {
+	Directory string `mapstructure:"directory" json:"directory" yaml:"directory"`
+}
+
+type ImportConfig struct {
+	Directory string `mapstructure:"directory" json:"directory" yaml:"directory"`
+}
+
 type Config struct {
-	Mongo MongoConfig `mapstructure:"mongo" json:"mongo" yaml:"mongo"`
-	MCP   MCPClient   `mapstructure:"mcp" json:"mcp" yaml:"mcp"`
+	Mongo       MongoConfig            `mapstructure:"mongo" json:"mongo" yaml:"mongo"`
+	MCP         MCPClient              `mapstructure:"mcp" json:"mcp" yaml:"mcp"`
+	IdGenerator IdGeneratorConfig      `mapstructure:"id_generator" json:"id_generator" yaml:"id_generator"`
+	Export      ExportConfig           `mapstructure:"export" json:"export" yaml:"export"`
+	Import      ImportConfig           `mapstructure:"import" json:"import" yaml:"import"`
+	Connections map[string]MongoConfig `mapstructure:"connections" json:"connections" yaml:"connections"`
 }
 
 func LoadConfig(path string, env string) Config {
@@ -1,11 +1,13 @@
 package app
 
 import (
+	"context"
 	"github.com/mark3labs/mcp-go/server"
+	"mcp/app/configs"
 	"mcp/app/tools"
 )
 
-func AddTools(s *server.MCPServer) {
+func AddTools(s *server.MCPServer, config configs.Config) {
 	// Add Collection tools to MCP server
 	collTool := tools.NewCollectionTool()
 	AddCollectionTools(s, collTool)
@@ -18,13 +20,70 @@ func AddTools(s *server.MCPServer) {
 	indexTool := tools.NewIndexTool()
 	AddIndexTools(s, indexTool)
 
-	idGenerateTool := tools.NewIdGenerateTool()
+	// Add Search Index tools to MCP server
+	searchIndexTool := tools.NewSearchIndexTool()
+	AddSearchIndexTools(s, searchIndexTool)
+
+	// Add Validation tools to MCP server
+	validationTool := tools.NewValidationTool()
+	AddValidationTools(s, validationTool)
+
+	// Add Time-series tools to MCP server
+	timeSeriesTool := tools.NewTimeSeriesTool()
+	AddTimeSeriesTools(s, timeSeriesTool)
+
+	// Add GridFS tools to MCP server
+	gridFSTool := tools.NewGridFSTool()
+	AddGridFSTools(s, gridFSTool)
+
+	// Add Export tool and resource to MCP server
+	exportTool := tools.NewExportTool(config.Export)
+	AddExportTools(s, exportTool)
+
+	// Add Import tool to MCP server
+	importTool := tools.NewImportTool(config.Import)
+	AddImportTools(s, importTool)
+
+	// Add Copy tools to MCP server
+	copyTool := tools.NewCopyTool(config.Connections)
+	AddCopyTools(s, copyTool)
+
+	// Add Geo tools to MCP server
+	geoTool := tools.NewGeoTool()
+	AddGeoTools(s, geoTool)
+
+	idGenerateTool := tools.NewIdGenerateTool(config.IdGenerator)
 	AddIdGenerateTools(s, idGenerateTool)
+
+	// Add Transaction tools to MCP server
+	transactionTool := tools.NewTransactionTool()
+	AddTransactionTools(s, transactionTool)
+
+	// Add Watch tools and resource to MCP server
+	watchTool := tools.NewWatchTool()
+	AddWatchTools(s, watchTool)
+}
+
+// NewHooks creates the MCP server hooks, which release the state of a session once its client disconnects
+func NewHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		go func() {
+			<-ctx.Done()
+			tools.AbortSessionTransactions(session.SessionID())
+			tools.CloseSessionWatches(session.SessionID())
+		}()
+	})
+	return hooks
 }
 
 // AddCollectionTools adds collection tools to the MCP server
 func AddCollectionTools(s *server.MCPServer, collTool tools.CollectionTool) {
 	s.AddTool(collTool.ListCollections())
+	s.AddTool(collTool.CreateView())
+	s.AddTool(collTool.ListViews())
+	s.AddTool(collTool.ModifyView())
+	s.AddTool(collTool.DropView())
 }
 
 // AddDocumentTools adds collection tools to the MCP server
@@ -34,6 +93,9 @@ func AddDocumentTools(s *server.MCPServer, docTool tools.DocumentTool) {
 	s.AddTool(docTool.InsertOne())
 	s.AddTool(docTool.DeleteOne())
 	s.AddTool(docTool.UpdateOne())
+	s.AddTool(docTool.Distinct())
+	s.AddTool(docTool.VectorSearch())
+	s.AddTool(docTool.TextSearch())
 }
 
 // AddIndexTools adds collection tools to the MCP server
@@ -41,8 +103,90 @@ func AddIndexTools(s *server.MCPServer, indexTool tools.IndexTool) {
 	s.AddTool(indexTool.CreateIndex())
 	s.AddTool(indexTool.ListIndexes())
 	s.AddTool(indexTool.DropIndex())
+	s.AddTool(indexTool.CreateIndexes())
+	s.AddTool(indexTool.IndexBuildProgress())
+	s.AddTool(indexTool.IndexUsage())
+	s.AddTool(indexTool.SuggestIndexes())
+	s.AddTool(indexTool.HideIndex())
+	s.AddTool(indexTool.UnhideIndex())
+	s.AddTool(indexTool.ModifyIndex())
+}
+
+// AddSearchIndexTools adds search index tools to the MCP server
+func AddSearchIndexTools(s *server.MCPServer, searchIndexTool tools.SearchIndexTool) {
+	s.AddTool(searchIndexTool.ListSearchIndexes())
+	s.AddTool(searchIndexTool.CreateSearchIndex())
+	s.AddTool(searchIndexTool.UpdateSearchIndex())
+	s.AddTool(searchIndexTool.DropSearchIndex())
+}
+
+// AddValidationTools adds schema validation tools to the MCP server
+func AddValidationTools(s *server.MCPServer, validationTool tools.ValidationTool) {
+	s.AddTool(validationTool.GetValidator())
+	s.AddTool(validationTool.SetValidator())
+	s.AddTool(validationTool.ValidateDocuments())
+	s.AddTool(validationTool.InferValidator())
+}
+
+// AddTimeSeriesTools adds time-series tools to the MCP server
+func AddTimeSeriesTools(s *server.MCPServer, timeSeriesTool tools.TimeSeriesTool) {
+	s.AddTool(timeSeriesTool.CreateTimeSeries())
+	s.AddTool(timeSeriesTool.TimeSeriesQuery())
+}
+
+// AddGridFSTools adds GridFS file tools to the MCP server
+func AddGridFSTools(s *server.MCPServer, gridFSTool tools.GridFSTool) {
+	s.AddTool(gridFSTool.ListGridFSBuckets())
+	s.AddTool(gridFSTool.ListGridFSFiles())
+	s.AddTool(gridFSTool.GetGridFSFile())
+	s.AddTool(gridFSTool.DownloadGridFSFile())
+	s.AddTool(gridFSTool.UploadGridFSFile())
+	s.AddTool(gridFSTool.DeleteGridFSFile())
+}
+
+// AddExportTools adds the export tool and resource to the MCP server
+func AddExportTools(s *server.MCPServer, exportTool tools.ExportTool) {
+	s.AddTool(exportTool.Export())
+	s.AddResourceTemplate(exportTool.ExportResource())
+}
+
+// AddImportTools adds the import tool to the MCP server
+func AddImportTools(s *server.MCPServer, importTool tools.ImportTool) {
+	s.AddTool(importTool.Import())
+}
+
+// AddCopyTools adds collection copy tools to the MCP server
+func AddCopyTools(s *server.MCPServer, copyTool tools.CopyTool) {
+	s.AddTool(copyTool.CopyCollection())
+	s.AddTool(copyTool.CopyProgress())
+}
+
+// AddGeoTools adds geospatial query tools to the MCP server
+func AddGeoTools(s *server.MCPServer, geoTool tools.GeoTool) {
+	s.AddTool(geoTool.GeoNear())
+	s.AddTool(geoTool.GeoWithin())
 }
 
 func AddIdGenerateTools(s *server.MCPServer, idGenerateTool tools.IdGenerateTool) {
 	s.AddTool(idGenerateTool.Generate())
+	s.AddTool(idGenerateTool.ListCounters())
+	s.AddTool(idGenerateTool.PeekId())
+	s.AddTool(idGenerateTool.SetCounter())
+	s.AddTool(idGenerateTool.ReconcileCounter())
+	s.AddTool(idGenerateTool.ListScopes())
+}
+
+// AddTransactionTools adds transaction tools to the MCP server
+func AddTransactionTools(s *server.MCPServer, transactionTool tools.TransactionTool) {
+	s.AddTool(transactionTool.StartTransaction())
+	s.AddTool(transactionTool.CommitTransaction())
+	s.AddTool(transactionTool.AbortTransaction())
+}
+
+// AddWatchTools adds change stream tools and resource to the MCP server
+func AddWatchTools(s *server.MCPServer, watchTool tools.WatchTool) {
+	s.AddTool(watchTool.Watch())
+	s.AddTool(watchTool.WatchSubscribe())
+	s.AddTool(watchTool.WatchUnsubscribe())
+	s.AddResourceTemplate(watchTool.WatchResource())
 }
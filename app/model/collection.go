@@ -0,0 +1,20 @@
+package model
+
+import "go.mongodb.org/mongo-driver/mongo/options"
+
+type CreateViewRequest struct {
+	Name      string             `mapstructure:"name" json:"name"`
+	ViewOn    string             `mapstructure:"view_on" json:"view_on"`
+	Pipeline  string             `mapstructure:"pipeline" json:"pipeline"`
+	Collation *options.Collation `mapstructure:"collation" json:"collation"`
+}
+
+type ViewRequest struct {
+	Name string `mapstructure:"name" json:"name"`
+}
+
+type ModifyViewRequest struct {
+	Name     string `mapstructure:"name" json:"name"`
+	ViewOn   string `mapstructure:"view_on" json:"view_on"`
+	Pipeline string `mapstructure:"pipeline" json:"pipeline"`
+}
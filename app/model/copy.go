@@ -0,0 +1,21 @@
+package model
+
+type CopyCollectionRequest struct {
+	Collection       string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	SourceDatabase   string                 `mapstructure:"source_database" json:"source_database" bson:"sourceDatabase"`
+	SourceConnection string                 `mapstructure:"source_connection" json:"source_connection" bson:"sourceConnection"`
+	Target           string                 `mapstructure:"target" json:"target" bson:"target"`
+	TargetDatabase   string                 `mapstructure:"target_database" json:"target_database" bson:"targetDatabase"`
+	TargetConnection string                 `mapstructure:"target_connection" json:"target_connection" bson:"targetConnection"`
+	Filter           map[string]interface{} `mapstructure:"filter" json:"filter" bson:"-"`
+	Pipeline         string                 `mapstructure:"pipeline" json:"pipeline" bson:"pipeline"`
+	CopyIndexes      bool                   `mapstructure:"copy_indexes" json:"copy_indexes" bson:"copyIndexes"`
+	CopyValidator    bool                   `mapstructure:"copy_validator" json:"copy_validator" bson:"copyValidator"`
+	DropTarget       bool                   `mapstructure:"drop_target" json:"drop_target" bson:"dropTarget"`
+	BatchSize        int                    `mapstructure:"batch_size" json:"batch_size" bson:"batchSize"`
+	ResumeID         string                 `mapstructure:"resume_id" json:"resume_id" bson:"-"`
+}
+
+type CopyProgressRequest struct {
+	CopyID string `mapstructure:"copy_id" json:"copy_id"`
+}
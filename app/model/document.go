@@ -1,30 +1,71 @@
 package model
 
+import "go.mongodb.org/mongo-driver/mongo/options"
+
 type FindDocumentRequest struct {
-	Collection string                 `mapstructure:"collection" json:"collection" bson:"collection"`
-	Filter     map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
-	Limit      int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
-	Projection map[string]interface{} `mapstructure:"projection" json:"projection" bson:"projection"`
+	Collection    string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	Limit         int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
+	Projection    map[string]interface{} `mapstructure:"projection" json:"projection" bson:"projection"`
+	TransactionID string                 `mapstructure:"transaction_id" json:"transaction_id" bson:"transaction_id"`
 }
 
 type CountDocumentRequest struct {
-	Collection string                 `mapstructure:"collection" json:"collection" bson:"collection"`
-	Filter     map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
-	Limit      int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
+	Collection    string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	Limit         int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
+	Exact         *bool                  `mapstructure:"exact" json:"exact" bson:"exact"`
+	MaxTimeMS     int64                  `mapstructure:"max_time_ms" json:"max_time_ms" bson:"max_time_ms"`
+	TransactionID string                 `mapstructure:"transaction_id" json:"transaction_id" bson:"transaction_id"`
 }
 
 type InsertDocumentRequest struct {
-	Collection string `mapstructure:"collection" json:"collection" bson:"collection"`
-	Document   string `mapstructure:"document" json:"document" bson:"document"`
+	Collection    string `mapstructure:"collection" json:"collection" bson:"collection"`
+	Document      string `mapstructure:"document" json:"document" bson:"document"`
+	TransactionID string `mapstructure:"transaction_id" json:"transaction_id" bson:"transaction_id"`
 }
 
 type DeleteDocumentRequest struct {
-	Collection string                 `mapstructure:"collection" json:"collection" bson:"collection"`
-	Filter     map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	Collection    string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	TransactionID string                 `mapstructure:"transaction_id" json:"transaction_id" bson:"transaction_id"`
 }
 
 type UpdateDocumentRequest struct {
-	Collection string                 `mapstructure:"collection" json:"collection"`
-	Filter     map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
-	Update     map[string]interface{} `mapstructure:"update" json:"update" bson:"update"`
+	Collection    string                 `mapstructure:"collection" json:"collection"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	Update        map[string]interface{} `mapstructure:"update" json:"update" bson:"update"`
+	TransactionID string                 `mapstructure:"transaction_id" json:"transaction_id" bson:"transaction_id"`
+}
+
+type DistinctDocumentRequest struct {
+	Collection    string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	Field         string                 `mapstructure:"field" json:"field" bson:"field"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	Collation     *options.Collation     `mapstructure:"collation" json:"collation" bson:"collation"`
+	Limit         int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
+	TransactionID string                 `mapstructure:"transaction_id" json:"transaction_id" bson:"transaction_id"`
+}
+
+type VectorSearchRequest struct {
+	Collection    string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	Index         string                 `mapstructure:"index" json:"index" bson:"index"`
+	Path          string                 `mapstructure:"path" json:"path" bson:"path"`
+	QueryVector   []float64              `mapstructure:"query_vector" json:"query_vector" bson:"query_vector"`
+	QueryDocument map[string]interface{} `mapstructure:"query_document" json:"query_document" bson:"query_document"`
+	NumCandidates int64                  `mapstructure:"num_candidates" json:"num_candidates" bson:"num_candidates"`
+	Limit         int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	ExcludeFields []string               `mapstructure:"exclude_fields" json:"exclude_fields" bson:"exclude_fields"`
+}
+
+type TextSearchRequest struct {
+	Collection         string                 `mapstructure:"collection" json:"collection" bson:"collection"`
+	Search             string                 `mapstructure:"search" json:"search" bson:"search"`
+	Language           string                 `mapstructure:"language" json:"language" bson:"language"`
+	CaseSensitive      bool                   `mapstructure:"case_sensitive" json:"case_sensitive" bson:"case_sensitive"`
+	DiacriticSensitive bool                   `mapstructure:"diacritic_sensitive" json:"diacritic_sensitive" bson:"diacritic_sensitive"`
+	Filter             map[string]interface{} `mapstructure:"filter" json:"filter" bson:"filter"`
+	Projection         map[string]interface{} `mapstructure:"projection" json:"projection" bson:"projection"`
+	Limit              int64                  `mapstructure:"limit" json:"limit" bson:"limit"`
 }
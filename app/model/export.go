@@ -0,0 +1,13 @@
+package model
+
+type ExportRequest struct {
+	Collection string                 `mapstructure:"collection" json:"collection"`
+	Filter     map[string]interface{} `mapstructure:"filter" json:"filter"`
+	Projection map[string]interface{} `mapstructure:"projection" json:"projection"`
+	Sort       string                 `mapstructure:"sort" json:"sort"`
+	Pipeline   string                 `mapstructure:"pipeline" json:"pipeline"`
+	Limit      int64                  `mapstructure:"limit" json:"limit"`
+	Format     string                 `mapstructure:"format" json:"format"`
+	Filename   string                 `mapstructure:"filename" json:"filename"`
+	Overwrite  bool                   `mapstructure:"overwrite" json:"overwrite"`
+}
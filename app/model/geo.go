@@ -0,0 +1,26 @@
+package model
+
+type GeoNearRequest struct {
+	Collection    string                 `mapstructure:"collection" json:"collection"`
+	Near          map[string]interface{} `mapstructure:"near" json:"near"`
+	Key           string                 `mapstructure:"key" json:"key"`
+	MinDistance   float64                `mapstructure:"min_distance" json:"min_distance"`
+	MaxDistance   float64                `mapstructure:"max_distance" json:"max_distance"`
+	Unit          string                 `mapstructure:"unit" json:"unit"`
+	Spherical     *bool                  `mapstructure:"spherical" json:"spherical"`
+	Filter        map[string]interface{} `mapstructure:"filter" json:"filter"`
+	DistanceField string                 `mapstructure:"distance_field" json:"distance_field"`
+	Limit         int64                  `mapstructure:"limit" json:"limit"`
+}
+
+type GeoWithinRequest struct {
+	Collection string                 `mapstructure:"collection" json:"collection"`
+	Field      string                 `mapstructure:"field" json:"field"`
+	Geometry   map[string]interface{} `mapstructure:"geometry" json:"geometry"`
+	Center     []float64              `mapstructure:"center" json:"center"`
+	Radius     float64                `mapstructure:"radius" json:"radius"`
+	Box        [][]float64            `mapstructure:"box" json:"box"`
+	Unit       string                 `mapstructure:"unit" json:"unit"`
+	Filter     map[string]interface{} `mapstructure:"filter" json:"filter"`
+	Limit      int64                  `mapstructure:"limit" json:"limit"`
+}
@@ -0,0 +1,29 @@
+package model
+
+type ListGridFSFilesRequest struct {
+	Bucket string                 `mapstructure:"bucket" json:"bucket"`
+	Filter map[string]interface{} `mapstructure:"filter" json:"filter"`
+	Limit  int64                  `mapstructure:"limit" json:"limit"`
+}
+
+type GridFSFileRequest struct {
+	Bucket   string `mapstructure:"bucket" json:"bucket"`
+	FileID   string `mapstructure:"file_id" json:"file_id"`
+	Filename string `mapstructure:"filename" json:"filename"`
+}
+
+type DownloadGridFSFileRequest struct {
+	Bucket   string `mapstructure:"bucket" json:"bucket"`
+	FileID   string `mapstructure:"file_id" json:"file_id"`
+	Filename string `mapstructure:"filename" json:"filename"`
+	As       string `mapstructure:"as" json:"as"`
+	MaxBytes int64  `mapstructure:"max_bytes" json:"max_bytes"`
+}
+
+type UploadGridFSFileRequest struct {
+	Bucket      string                 `mapstructure:"bucket" json:"bucket"`
+	Filename    string                 `mapstructure:"filename" json:"filename"`
+	Content     string                 `mapstructure:"content" json:"content"`
+	ContentType string                 `mapstructure:"content_type" json:"content_type"`
+	Metadata    map[string]interface{} `mapstructure:"metadata" json:"metadata"`
+}
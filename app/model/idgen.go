@@ -0,0 +1,30 @@
+package model
+
+type GenerateIdRequest struct {
+	EntityType string `mapstructure:"entity_type" json:"entity_type"`
+	Count      int    `mapstructure:"count" json:"count"`
+	Scope      string `mapstructure:"scope" json:"scope"`
+}
+
+type CounterRequest struct {
+	EntityType string `mapstructure:"entity_type" json:"entity_type"`
+	Period     string `mapstructure:"period" json:"period"`
+	Scope      string `mapstructure:"scope" json:"scope"`
+}
+
+type SetCounterRequest struct {
+	EntityType string `mapstructure:"entity_type" json:"entity_type"`
+	Period     string `mapstructure:"period" json:"period"`
+	Scope      string `mapstructure:"scope" json:"scope"`
+	NextValue  int64  `mapstructure:"next_value" json:"next_value"`
+	Reset      bool   `mapstructure:"reset" json:"reset"`
+	Confirm    bool   `mapstructure:"confirm" json:"confirm"`
+}
+
+type ReconcileCounterRequest struct {
+	EntityType string `mapstructure:"entity_type" json:"entity_type"`
+	Period     string `mapstructure:"period" json:"period"`
+	Scope      string `mapstructure:"scope" json:"scope"`
+	Collection string `mapstructure:"collection" json:"collection"`
+	Field      string `mapstructure:"field" json:"field"`
+}
@@ -0,0 +1,12 @@
+package model
+
+type ImportRequest struct {
+	Collection string            `mapstructure:"collection" json:"collection"`
+	Filename   string            `mapstructure:"filename" json:"filename"`
+	Content    string            `mapstructure:"content" json:"content"`
+	Format     string            `mapstructure:"format" json:"format"`
+	Types      map[string]string `mapstructure:"types" json:"types"`
+	Mode       string            `mapstructure:"mode" json:"mode"`
+	Key        []string          `mapstructure:"key" json:"key"`
+	BatchSize  int               `mapstructure:"batch_size" json:"batch_size"`
+}
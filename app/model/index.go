@@ -1,10 +1,61 @@
 package model
 
+import "go.mongodb.org/mongo-driver/mongo/options"
+
 type CreateIndexRequest struct {
-	Collection string                 `mapstructure:"collection" json:"collection"`
-	IndexSpec  map[string]interface{} `mapstructure:"index_spec" json:"index_spec"`
+	Collection              string                 `mapstructure:"collection" json:"collection"`
+	IndexSpec               map[string]interface{} `mapstructure:"index_spec" json:"index_spec"`
+	Keys                    string                 `mapstructure:"keys" json:"keys"`
+	Name                    string                 `mapstructure:"name" json:"name"`
+	Unique                  bool                   `mapstructure:"unique" json:"unique"`
+	Sparse                  bool                   `mapstructure:"sparse" json:"sparse"`
+	Hidden                  bool                   `mapstructure:"hidden" json:"hidden"`
+	PartialFilterExpression map[string]interface{} `mapstructure:"partial_filter_expression" json:"partial_filter_expression"`
+	ExpireAfterSeconds      *int32                 `mapstructure:"expire_after_seconds" json:"expire_after_seconds"`
+	Collation               *options.Collation     `mapstructure:"collation" json:"collation"`
+	WildcardProjection      map[string]interface{} `mapstructure:"wildcard_projection" json:"wildcard_projection"`
+	Weights                 map[string]interface{} `mapstructure:"weights" json:"weights"`
+	DefaultLanguage         string                 `mapstructure:"default_language" json:"default_language"`
+	LanguageOverride        string                 `mapstructure:"language_override" json:"language_override"`
+	SphereVersion           int32                  `mapstructure:"sphere_version" json:"sphere_version"`
 }
 type DropIndexRequest struct {
 	Collection string `mapstructure:"collection" json:"collection"`
 	IndexName  string `mapstructure:"index_name" json:"index_name"`
 }
+
+type HideIndexRequest struct {
+	Collection string `mapstructure:"collection" json:"collection"`
+	IndexName  string `mapstructure:"index_name" json:"index_name"`
+}
+
+type ModifyIndexRequest struct {
+	Collection         string `mapstructure:"collection" json:"collection"`
+	IndexName          string `mapstructure:"index_name" json:"index_name"`
+	ExpireAfterSeconds *int64 `mapstructure:"expire_after_seconds" json:"expire_after_seconds"`
+	Unique             bool   `mapstructure:"unique" json:"unique"`
+}
+
+type CreateIndexesRequest struct {
+	Collection   string `mapstructure:"collection" json:"collection"`
+	Indexes      string `mapstructure:"indexes" json:"indexes"`
+	CommitQuorum string `mapstructure:"commit_quorum" json:"commit_quorum"`
+}
+
+type IndexBuildProgressRequest struct {
+	Collection  string `mapstructure:"collection" json:"collection"`
+	OperationID string `mapstructure:"operation_id" json:"operation_id"`
+}
+
+type IndexUsageRequest struct {
+	Collection string `mapstructure:"collection" json:"collection"`
+}
+
+type SuggestIndexesRequest struct {
+	Collection    string `mapstructure:"collection" json:"collection"`
+	Queries       string `mapstructure:"queries" json:"queries"`
+	FromProfiler  bool   `mapstructure:"from_profiler" json:"from_profiler"`
+	ProfilerLimit int64  `mapstructure:"profiler_limit" json:"profiler_limit"`
+	MinMillis     int64  `mapstructure:"min_millis" json:"min_millis"`
+	Estimate      bool   `mapstructure:"estimate" json:"estimate"`
+}
@@ -0,0 +1,22 @@
+package model
+
+type ListSearchIndexesRequest struct {
+	Collection string `mapstructure:"collection" json:"collection"`
+	Name       string `mapstructure:"name" json:"name"`
+}
+
+type SearchIndexRequest struct {
+	Collection    string   `mapstructure:"collection" json:"collection"`
+	Name          string   `mapstructure:"name" json:"name"`
+	Type          string   `mapstructure:"type" json:"type"`
+	Definition    string   `mapstructure:"definition" json:"definition"`
+	Path          string   `mapstructure:"path" json:"path"`
+	NumDimensions int      `mapstructure:"num_dimensions" json:"num_dimensions"`
+	Similarity    string   `mapstructure:"similarity" json:"similarity"`
+	FilterFields  []string `mapstructure:"filter_fields" json:"filter_fields"`
+}
+
+type DropSearchIndexRequest struct {
+	Collection string `mapstructure:"collection" json:"collection"`
+	Name       string `mapstructure:"name" json:"name"`
+}
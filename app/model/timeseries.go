@@ -0,0 +1,27 @@
+package model
+
+type CreateTimeSeriesRequest struct {
+	Name                  string `mapstructure:"name" json:"name"`
+	TimeField             string `mapstructure:"time_field" json:"time_field"`
+	MetaField             string `mapstructure:"meta_field" json:"meta_field"`
+	Granularity           string `mapstructure:"granularity" json:"granularity"`
+	BucketMaxSpanSeconds  int64  `mapstructure:"bucket_max_span_seconds" json:"bucket_max_span_seconds"`
+	BucketRoundingSeconds int64  `mapstructure:"bucket_rounding_seconds" json:"bucket_rounding_seconds"`
+	ExpireAfterSeconds    *int64 `mapstructure:"expire_after_seconds" json:"expire_after_seconds"`
+}
+
+type TimeSeriesQueryRequest struct {
+	Collection   string                 `mapstructure:"collection" json:"collection"`
+	TimeField    string                 `mapstructure:"time_field" json:"time_field"`
+	Start        string                 `mapstructure:"start" json:"start"`
+	End          string                 `mapstructure:"end" json:"end"`
+	Unit         string                 `mapstructure:"unit" json:"unit"`
+	BinSize      int64                  `mapstructure:"bin_size" json:"bin_size"`
+	Timezone     string                 `mapstructure:"timezone" json:"timezone"`
+	GroupBy      string                 `mapstructure:"group_by" json:"group_by"`
+	Fields       []string               `mapstructure:"fields" json:"fields"`
+	Aggregations []string               `mapstructure:"aggregations" json:"aggregations"`
+	Filter       map[string]interface{} `mapstructure:"filter" json:"filter"`
+	MovingWindow int64                  `mapstructure:"moving_window" json:"moving_window"`
+	Limit        int64                  `mapstructure:"limit" json:"limit"`
+}
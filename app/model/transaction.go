@@ -0,0 +1,9 @@
+package model
+
+type StartTransactionRequest struct {
+	TimeoutSeconds int64 `mapstructure:"timeout_seconds" json:"timeout_seconds"`
+}
+
+type TransactionRequest struct {
+	TransactionID string `mapstructure:"transaction_id" json:"transaction_id"`
+}
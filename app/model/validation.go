@@ -0,0 +1,26 @@
+package model
+
+type GetValidatorRequest struct {
+	Collection string `mapstructure:"collection" json:"collection"`
+}
+
+type SetValidatorRequest struct {
+	Collection       string `mapstructure:"collection" json:"collection"`
+	Validator        string `mapstructure:"validator" json:"validator"`
+	ValidationLevel  string `mapstructure:"validation_level" json:"validation_level"`
+	ValidationAction string `mapstructure:"validation_action" json:"validation_action"`
+}
+
+type ValidateDocumentsRequest struct {
+	Collection string                 `mapstructure:"collection" json:"collection"`
+	Validator  string                 `mapstructure:"validator" json:"validator"`
+	Filter     map[string]interface{} `mapstructure:"filter" json:"filter"`
+	SampleSize int64                  `mapstructure:"sample_size" json:"sample_size"`
+}
+
+type InferValidatorRequest struct {
+	Collection    string  `mapstructure:"collection" json:"collection"`
+	SampleSize    int64   `mapstructure:"sample_size" json:"sample_size"`
+	RequiredRatio float64 `mapstructure:"required_ratio" json:"required_ratio"`
+	EnumMaxValues int     `mapstructure:"enum_max_values" json:"enum_max_values"`
+}
@@ -0,0 +1,20 @@
+package model
+
+type WatchRequest struct {
+	Collection      string `mapstructure:"collection" json:"collection"`
+	Pipeline        string `mapstructure:"pipeline" json:"pipeline"`
+	FullDocument    bool   `mapstructure:"full_document" json:"full_document"`
+	ResumeAfter     string `mapstructure:"resume_after" json:"resume_after"`
+	MaxEvents       int    `mapstructure:"max_events" json:"max_events"`
+	DurationSeconds int64  `mapstructure:"duration_seconds" json:"duration_seconds"`
+}
+
+type WatchSubscribeRequest struct {
+	Collection   string `mapstructure:"collection" json:"collection"`
+	Pipeline     string `mapstructure:"pipeline" json:"pipeline"`
+	FullDocument bool   `mapstructure:"full_document" json:"full_document"`
+}
+
+type WatchUnsubscribeRequest struct {
+	Collection string `mapstructure:"collection" json:"collection"`
+}
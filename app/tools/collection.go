@@ -2,16 +2,30 @@ package tools
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
 	"mcp/app/client"
+	"mcp/app/model"
 	"strings"
 )
 
 type CollectionTool interface {
 	// ListCollections get collections in mongodb
 	ListCollections() (mcp.Tool, server.ToolHandlerFunc)
+	// CreateView create a read-only view from an aggregation pipeline
+	CreateView() (mcp.Tool, server.ToolHandlerFunc)
+	// ListViews get views in mongodb with their source and pipeline
+	ListViews() (mcp.Tool, server.ToolHandlerFunc)
+	// ModifyView replace the source and pipeline of a view
+	ModifyView() (mcp.Tool, server.ToolHandlerFunc)
+	// DropView drop a view
+	DropView() (mcp.Tool, server.ToolHandlerFunc)
 }
 
 type collectionTool struct{}
@@ -20,27 +34,287 @@ func NewCollectionTool() CollectionTool {
 	return &collectionTool{}
 }
 
+var (
+	// errCollectionNotFound is wrapped by the lookups of a collection or view which doesn't exist
+	errCollectionNotFound = errors.New("not found")
+	// errNotView is returned by getView when the name is a collection
+	errNotView = errors.New("not a view")
+)
+
+// collectionInfo is an entry of listCollections
+type collectionInfo struct {
+	Name    string `bson:"name"`
+	Type    string `bson:"type"`
+	Options struct {
+		Validator        bson.D   `bson:"validator"`
+		ValidationLevel  string   `bson:"validationLevel"`
+		ValidationAction string   `bson:"validationAction"`
+		ViewOn           string   `bson:"viewOn"`
+		Pipeline         []bson.D `bson:"pipeline"`
+		TimeSeries       struct {
+			TimeField   string `bson:"timeField"`
+			MetaField   string `bson:"metaField"`
+			Granularity string `bson:"granularity"`
+		} `bson:"timeseries"`
+	} `bson:"options"`
+}
+
+// listCollectionInfos returns the listCollections entries matching filter
+func listCollectionInfos(ctx context.Context, filter interface{}) ([]collectionInfo, error) {
+	cur, err := client.DB.ListCollections(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var infos []collectionInfo
+	err = cur.All(ctx, &infos)
+	return infos, err
+}
+
+// getCollectionInfo returns the listCollections entry of a collection
+func getCollectionInfo(ctx context.Context, name string) (collectionInfo, error) {
+	infos, err := listCollectionInfos(ctx, bson.M{"name": name})
+	if err != nil {
+		return collectionInfo{}, err
+	}
+	if len(infos) == 0 {
+		return collectionInfo{}, fmt.Errorf("collection %s %w", name, errCollectionNotFound)
+	}
+	return infos[0], nil
+}
+
+// getView returns the listCollections entry of a view, failing when name is a collection
+func getView(ctx context.Context, name string) (collectionInfo, error) {
+	info, err := getCollectionInfo(ctx, name)
+	if errors.Is(err, errCollectionNotFound) {
+		return info, fmt.Errorf("view %s %w", name, errCollectionNotFound)
+	}
+	if err != nil {
+		return info, fmt.Errorf("get view %s: %w", name, err)
+	}
+	if info.Type != "view" {
+		return info, fmt.Errorf("%s is a %s, %w", name, info.Type, errNotView)
+	}
+	return info, nil
+}
+
 // ListCollections List all collections in mongodb
 func (c collectionTool) ListCollections() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 
 	// MCP Tool
 	tool = mcp.NewTool(
 		"ListCollections",
-		mcp.WithDescription("List all collections in mongodb"),
+		mcp.WithDescription("List all collections, time-series collections and views in mongodb"),
 	)
 	// handler
 	// request is empty, this tool will return all collections in mongodb
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		collections, err := client.DB.ListCollectionNames(ctx, bson.D{})
+		// the progress of the copies is internal to CopyCollection
+		infos, err := listCollectionInfos(ctx, bson.M{"name": bson.M{"$ne": copyCheckpointsCollection}})
 
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		if len(collections) == 0 {
+		if len(infos) == 0 {
 			return mcp.NewToolResultText("No collections found"), nil
 		}
-		result := mcp.NewToolResultText("Collections: " + strings.Join(collections, ", "))
-		return result, nil
+		var collections, views, timeSeries []string
+		for _, info := range infos {
+			switch info.Type {
+			case "view":
+				views = append(views, info.Name)
+			case "timeseries":
+				timeSeries = append(timeSeries, info.Name)
+			default:
+				collections = append(collections, info.Name)
+			}
+		}
+		result := "Collections: " + strings.Join(collections, ", ")
+		if len(timeSeries) > 0 {
+			result += "\nTime-series: " + strings.Join(timeSeries, ", ")
+		}
+		if len(views) > 0 {
+			result += "\nViews (read-only): " + strings.Join(views, ", ")
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// CreateView create a read-only view from an aggregation pipeline
+func (c collectionTool) CreateView() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CreateView",
+		mcp.WithDescription("Create a read-only view running an aggregation pipeline on a source collection or view, "+
+			"e.g. to publish a curated projection. Views are queried like collections"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("View name"),
+		),
+		mcp.WithString("view_on",
+			mcp.Required(),
+			mcp.Description("Source collection or view"),
+		),
+		mcp.WithString("pipeline",
+			mcp.Required(),
+			mcp.Description("Aggregation pipeline as an Extended JSON array "+
+				"(e.g., [{\"$match\": {\"status\": \"active\"}}, {\"$project\": {\"name\": 1, \"email\": 1}}])"),
+		),
+		mcp.WithObject("collation",
+			mcp.Description("Default collation of the view (e.g., { locale: 'en', strength: 2 })"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CreateViewRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		pipeline, err := parsePipeline(req.Pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		log.Printf("Create view %s on %s, pipeline: %s", req.Name, req.ViewOn, req.Pipeline)
+
+		opts := options.CreateView()
+		if req.Collation != nil {
+			opts.SetCollation(req.Collation)
+		}
+		if err = client.DB.CreateView(ctx, req.Name, req.ViewOn, pipeline, opts); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("View %s created on %s", req.Name, req.ViewOn)), nil
+	}
+	return
+}
+
+// ListViews get views in mongodb with their source and pipeline
+func (c collectionTool) ListViews() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ListViews",
+		mcp.WithDescription("List the views in mongodb with their source and pipeline"),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		views, err := listCollectionInfos(ctx, bson.M{"type": "view"})
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(views) == 0 {
+			return mcp.NewToolResultText("No views found"), nil
+		}
+
+		var result string
+		for _, view := range views {
+			pipeline, err := bson.MarshalExtJSON(bson.D{{Key: "pipeline", Value: view.Options.Pipeline}}, false, false)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			result += fmt.Sprintf("name: %s, view_on: %s, %s\n", view.Name, view.Options.ViewOn, pipeline)
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// ModifyView replace the source and pipeline of a view
+func (c collectionTool) ModifyView() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ModifyView",
+		mcp.WithDescription("Replace the pipeline of a view, and optionally its source, without dropping it"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("View name"),
+		),
+		mcp.WithString("pipeline",
+			mcp.Required(),
+			mcp.Description("New aggregation pipeline as an Extended JSON array"),
+		),
+		mcp.WithString("view_on",
+			mcp.Description("New source collection or view, defaults to the current one"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ModifyViewRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		pipeline, err := parsePipeline(req.Pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		view, err := getView(ctx, req.Name)
+		if errors.Is(err, errCollectionNotFound) || errors.Is(err, errNotView) {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if req.ViewOn == "" {
+			req.ViewOn = view.Options.ViewOn
+		}
+
+		log.Printf("Modify view %s on %s, pipeline: %s", req.Name, req.ViewOn, req.Pipeline)
+
+		// collMod requires both the source and the pipeline of a view
+		command := bson.D{
+			{Key: "collMod", Value: req.Name},
+			{Key: "viewOn", Value: req.ViewOn},
+			{Key: "pipeline", Value: pipeline},
+		}
+		if err = client.DB.RunCommand(ctx, command).Err(); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("View %s modified", req.Name)), nil
+	}
+	return
+}
+
+// DropView drop a view
+func (c collectionTool) DropView() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"DropView",
+		mcp.WithDescription("Drop a view, its source collection is left untouched. Refuses to drop collections"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("View name"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ViewRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		_, err = getView(ctx, req.Name)
+		if errors.Is(err, errCollectionNotFound) || errors.Is(err, errNotView) {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		log.Printf("Drop view %s", req.Name)
+		if err = client.DB.Collection(req.Name).Drop(ctx); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("View %s dropped", req.Name)), nil
 	}
 	return
 }
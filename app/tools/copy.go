@@ -0,0 +1,758 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/configs"
+	"mcp/app/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// copyCheckpointsCollection stores the progress of the copies in the server database, so an interrupted copy can be resumed
+const copyCheckpointsCollection = "copy_checkpoints"
+
+// copySourceIDField carries the source _id through the transform pipeline, the checkpoint is the last source _id copied
+const copySourceIDField = "_copySourceId"
+
+const (
+	copyStatusRunning     = "running"
+	copyStatusDone        = "done"
+	copyStatusFailed      = "failed"
+	copyStatusInterrupted = "interrupted"
+)
+
+// copyTransformStages are the stages allowed in a transform pipeline, they work document by document and keep the _id order
+var copyTransformStages = map[string]bool{
+	"$match":       true,
+	"$set":         true,
+	"$addFields":   true,
+	"$unset":       true,
+	"$project":     true,
+	"$replaceWith": true,
+	"$replaceRoot": true,
+	"$lookup":      true,
+	"$redact":      true,
+}
+
+type CopyTool interface {
+	// CopyCollection copy documents, indexes and validator of a collection to another collection, database or connection
+	CopyCollection() (mcp.Tool, server.ToolHandlerFunc)
+	// CopyProgress report the progress of copies
+	CopyProgress() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type copyTool struct {
+	connections map[string]configs.MongoConfig
+}
+
+func NewCopyTool(connections map[string]configs.MongoConfig) CopyTool {
+	return &copyTool{connections: connections}
+}
+
+// copyCheckpoint is the progress of a copy, stored after each batch
+type copyCheckpoint struct {
+	ID      string                      `bson:"_id"`
+	Request model.CopyCollectionRequest `bson:"request"`
+	// Filter is the canonical Extended JSON of the request filter, whose $ keys older servers refuse to store
+	Filter   string      `bson:"filter"`
+	LastID   interface{} `bson:"lastId"`
+	Copied   int64       `bson:"copied"`
+	Total    int64       `bson:"total"`
+	Status   string      `bson:"status"`
+	Error    string      `bson:"error,omitempty"`
+	Indexes  []string    `bson:"indexes,omitempty"`
+	Started  time.Time   `bson:"started"`
+	Updated  time.Time   `bson:"updated"`
+	Finished time.Time   `bson:"finished,omitempty"`
+}
+
+func (c copyCheckpoint) source() string {
+	source := c.Request.SourceDatabase + "." + c.Request.Collection
+	if c.Request.SourceConnection != "" {
+		source = c.Request.SourceConnection + ":" + source
+	}
+	return source
+}
+
+func (c copyCheckpoint) target() string {
+	target := c.Request.TargetDatabase + "." + c.Request.Target
+	if c.Request.TargetConnection != "" {
+		target = c.Request.TargetConnection + ":" + target
+	}
+	return target
+}
+
+// connectionRegistry keeps the clients of the configured connections, connected on first use
+type connectionRegistry struct {
+	mu      sync.Mutex
+	clients map[string]*mongo.Client
+}
+
+var connections = &connectionRegistry{clients: map[string]*mongo.Client{}}
+
+// get returns the client of a configured connection, the default client when name is empty
+func (r *connectionRegistry) get(name string, configured map[string]configs.MongoConfig) (*mongo.Client, error) {
+	if name == "" {
+		return client.MongoClient, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clients[name]; ok {
+		return c, nil
+	}
+	config, ok := configured[name]
+	if !ok {
+		return nil, fmt.Errorf("connection %s is not configured", name)
+	}
+	c, err := client.NewMongoClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("connection %s: %w", name, err)
+	}
+	r.clients[name] = c
+	return c, nil
+}
+
+// database returns the configured database of a connection, used when no database is given
+func (r *connectionRegistry) database(name string, configured map[string]configs.MongoConfig) string {
+	if name == "" {
+		return client.DB.Name()
+	}
+	return configured[name].Database
+}
+
+// copyRuns are the copies running in this process
+type copyRunRegistry struct {
+	mu   sync.Mutex
+	runs map[string]bool
+}
+
+var copyRuns = &copyRunRegistry{runs: map[string]bool{}}
+
+// start marks a copy as running, false when it is already
+func (r *copyRunRegistry) start(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.runs[id] {
+		return false
+	}
+	r.runs[id] = true
+	return true
+}
+
+func (r *copyRunRegistry) finish(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.runs, id)
+}
+
+func (r *copyRunRegistry) running(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs[id]
+}
+
+// databaseCollectionInfo returns the listCollections entry of a collection of any database
+func databaseCollectionInfo(ctx context.Context, db *mongo.Database, name string) (collectionInfo, error) {
+	cur, err := db.ListCollections(ctx, bson.M{"name": name})
+	if err != nil {
+		return collectionInfo{}, err
+	}
+	defer cur.Close(ctx)
+
+	var infos []collectionInfo
+	if err = cur.All(ctx, &infos); err != nil {
+		return collectionInfo{}, err
+	}
+	if len(infos) == 0 {
+		return collectionInfo{}, fmt.Errorf("collection %s not found in database %s", name, db.Name())
+	}
+	return infos[0], nil
+}
+
+// validateTransform checks the stages of a transform pipeline
+func validateTransform(pipeline mongo.Pipeline) error {
+	for i, stage := range pipeline {
+		if len(stage) != 1 {
+			return fmt.Errorf("stage %d must have exactly one operator", i)
+		}
+		if !copyTransformStages[stage[0].Key] {
+			return fmt.Errorf("stage %d: %s is not allowed in a transform pipeline, use one of $match, $set, $addFields, "+
+				"$unset, $project, $replaceWith, $replaceRoot, $lookup, $redact", i, stage[0].Key)
+		}
+	}
+	return nil
+}
+
+// isExclusionProjection tells if a $project keeps the fields it doesn't list
+func isExclusionProjection(projection bson.D) bool {
+	for _, e := range projection {
+		if e.Key != "_id" && !isExcluded(e.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// isExcluded tells if a $project value excludes its field, nested documents exclude
+// when all their fields do, and operator documents are expressions computing a value
+func isExcluded(value interface{}) bool {
+	switch value := value.(type) {
+	case bool:
+		return !value
+	case int32, int64, float64:
+		return fmt.Sprint(value) == "0"
+	case bson.D:
+		if len(value) == 0 || strings.HasPrefix(value[0].Key, "$") {
+			return false
+		}
+		for _, e := range value {
+			if !isExcluded(e.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// trackSourceID copies the source _id to copySourceIDField before the transform, and keeps it through
+// the stages which would drop it, so the checkpoint doesn't depend on the _id the transform outputs
+func trackSourceID(transform mongo.Pipeline) mongo.Pipeline {
+	keep := bson.D{{Key: copySourceIDField, Value: "$" + copySourceIDField}}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: copySourceIDField, Value: "$_id"}}}}}
+	for _, stage := range transform {
+		operator, value := stage[0].Key, stage[0].Value
+		switch operator {
+		case "$project":
+			if projection, ok := value.(bson.D); ok && !isExclusionProjection(projection) {
+				value = append(projection[:len(projection):len(projection)], bson.E{Key: copySourceIDField, Value: 1})
+			}
+		case "$replaceWith":
+			value = bson.D{{Key: "$mergeObjects", Value: bson.A{value, keep}}}
+		case "$replaceRoot":
+			if spec, ok := value.(bson.D); ok {
+				newRoot, _ := lookup(spec, "newRoot")
+				value = bson.D{{Key: "newRoot", Value: bson.D{{Key: "$mergeObjects", Value: bson.A{newRoot, keep}}}}}
+			}
+		}
+		pipeline = append(pipeline, bson.D{{Key: operator, Value: value}})
+	}
+	return pipeline
+}
+
+// copyTotal counts the documents to copy, running the transform when its stages may leave documents out
+func copyTotal(ctx context.Context, coll *mongo.Collection, filter bson.D, transform mongo.Pipeline) (int64, error) {
+	filtering := false
+	for _, stage := range transform {
+		if stage[0].Key == "$match" || stage[0].Key == "$redact" {
+			filtering = true
+		}
+	}
+	if !filtering {
+		return coll.CountDocuments(ctx, filter)
+	}
+	pipeline := append(mongo.Pipeline{{{Key: "$match", Value: filter}}}, trackSourceID(transform)...)
+	pipeline = append(pipeline, bson.D{{Key: "$count", Value: "total"}})
+	cur, err := coll.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var counts []struct {
+		Total int64 `bson:"total"`
+	}
+	if err = cur.All(ctx, &counts); err != nil || len(counts) == 0 {
+		return 0, err
+	}
+	return counts[0].Total, nil
+}
+
+// CopyCollection copy documents, indexes and validator of a collection to another collection, database or connection
+func (c copyTool) CopyCollection() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CopyCollection",
+		mcp.WithDescription("Copy the documents matching a filter, optionally transformed by a pipeline, with the indexes and validator "+
+			"of a collection to another collection, database or configured connection. The copy runs in background in batches "+
+			"ordered by _id, the returned copy_id can be passed to CopyProgress, or to resume_id to resume an interrupted copy"),
+		mcp.WithString("collection",
+			mcp.Description("Source collection name, required unless resume_id is given"),
+		),
+		mcp.WithString("source_database",
+			mcp.Description("Source database, default is the database of the source connection"),
+		),
+		mcp.WithString("source_connection",
+			mcp.Description("Configured connection to read from, default is the server connection"),
+		),
+		mcp.WithString("target",
+			mcp.Description("Target collection name, default is the source collection name"),
+		),
+		mcp.WithString("target_database",
+			mcp.Description("Target database, default is the database of the target connection"),
+		),
+		mcp.WithString("target_connection",
+			mcp.Description("Configured connection to write to, default is the server connection"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("Only copy the documents matching this MongoDB query filter"),
+		),
+		mcp.WithString("pipeline",
+			mcp.Description("Transform pipeline as an Extended JSON array applied to each document, e.g. to mask fields "+
+				"(e.g., [{\"$set\": {\"email\": \"redacted\"}}]). Only $match, $set, $addFields, $unset, $project, $replaceWith, "+
+				"$replaceRoot, $lookup and $redact are allowed, and the documents must keep an _id"),
+		),
+		mcp.WithBoolean("copy_indexes",
+			mcp.Description("Create the indexes of the source collection on the target once the documents are copied"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithBoolean("copy_validator",
+			mcp.Description("Set the validator of the source collection on the target once the documents are copied"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithBoolean("drop_target",
+			mcp.Description("Drop the target collection first, the copy refuses to write to a non-empty target otherwise"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("batch_size",
+			mcp.Description("Number of documents written and checkpointed at a time"),
+			mcp.DefaultNumber(1000),
+			mcp.Min(1),
+			mcp.Max(10000),
+		),
+		mcp.WithString("resume_id",
+			mcp.Description("copy_id of an interrupted or failed copy to resume after its last copied document, "+
+				"the other arguments are ignored"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CopyCollectionRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		checkpoints := client.DB.Collection(copyCheckpointsCollection)
+
+		if req.ResumeID != "" {
+			var checkpoint copyCheckpoint
+			err = checkpoints.FindOne(ctx, bson.M{"_id": req.ResumeID}).Decode(&checkpoint)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return mcp.NewToolResultText(fmt.Sprintf("copy %s not found", req.ResumeID)), nil
+			}
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			if checkpoint.Status == copyStatusDone {
+				return mcp.NewToolResultText(fmt.Sprintf("copy %s is already done, %d documents copied", checkpoint.ID, checkpoint.Copied)), nil
+			}
+			source, target, err := c.clients(checkpoint.Request)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			// the target was empty when the copy started, so it holds exactly the copied documents,
+			// including a batch written after the last checkpoint, which is written again without being counted
+			targetColl := target.Database(checkpoint.Request.TargetDatabase).Collection(checkpoint.Request.Target)
+			copied, err := targetColl.CountDocuments(ctx, bson.D{})
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			checkpoint.Copied = copied
+			checkpoint.Status = copyStatusRunning
+			checkpoint.Error = ""
+			if !c.start(checkpoint, source, target) {
+				return mcp.NewToolResultText(fmt.Sprintf("copy %s is still running", checkpoint.ID)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("Copy %s resumed from %s to %s, %d of %d documents already copied",
+				checkpoint.ID, checkpoint.source(), checkpoint.target(), checkpoint.Copied, checkpoint.Total)), nil
+		}
+
+		if req.Collection == "" {
+			return mcp.NewToolResultText("collection is required unless resume_id is given"), nil
+		}
+		if _, ok := request.Params.Arguments["copy_indexes"]; !ok {
+			req.CopyIndexes = true
+		}
+		if _, ok := request.Params.Arguments["copy_validator"]; !ok {
+			req.CopyValidator = true
+		}
+		if req.BatchSize <= 0 {
+			req.BatchSize = 1000
+		}
+		if req.Target == "" {
+			req.Target = req.Collection
+		}
+		if req.SourceDatabase == "" {
+			req.SourceDatabase = connections.database(req.SourceConnection, c.connections)
+		}
+		if req.TargetDatabase == "" {
+			req.TargetDatabase = connections.database(req.TargetConnection, c.connections)
+		}
+		if req.SourceConnection == req.TargetConnection && req.SourceDatabase == req.TargetDatabase && req.Collection == req.Target {
+			return mcp.NewToolResultText("source and target are the same collection"), nil
+		}
+		for _, end := range []struct{ connection, database, collection string }{
+			{req.SourceConnection, req.SourceDatabase, req.Collection},
+			{req.TargetConnection, req.TargetDatabase, req.Target},
+		} {
+			if end.connection == "" && end.database == client.DB.Name() && end.collection == copyCheckpointsCollection {
+				return mcp.NewToolResultText(fmt.Sprintf("%s holds the progress of the copies and can't be copied", copyCheckpointsCollection)), nil
+			}
+		}
+		filterJSON, err := bson.MarshalExtJSON(req.Filter, true, false)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("invalid filter: %v", err)), nil
+		}
+		filter, err := parseDocument(string(filterJSON))
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("invalid filter: %v", err)), nil
+		}
+		transform, err := parsePipeline(req.Pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if err = validateTransform(transform); err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		source, target, err := c.clients(req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		sourceDB := source.Database(req.SourceDatabase)
+		if _, err = databaseCollectionInfo(ctx, sourceDB, req.Collection); err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		targetColl := target.Database(req.TargetDatabase).Collection(req.Target)
+		if req.DropTarget {
+			if err = targetColl.Drop(ctx); err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+		} else {
+			count, err := targetColl.CountDocuments(ctx, bson.D{})
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			if count > 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("target collection %s.%s already has %d documents, "+
+					"set drop_target to replace it", req.TargetDatabase, req.Target, count)), nil
+			}
+		}
+		total, err := copyTotal(ctx, sourceDB.Collection(req.Collection), filter, transform)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		now := time.Now()
+		checkpoint := copyCheckpoint{
+			ID:      primitive.NewObjectID().Hex(),
+			Request: req,
+			Filter:  string(filterJSON),
+			Total:   total,
+			Status:  copyStatusRunning,
+			Started: now,
+			Updated: now,
+		}
+		if _, err = checkpoints.InsertOne(ctx, checkpoint); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if !c.start(checkpoint, source, target) {
+			return mcp.NewToolResultText(fmt.Sprintf("copy %s is still running", checkpoint.ID)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Copy started from %s to %s, copy_id: %s, documents to copy: %d",
+			checkpoint.source(), checkpoint.target(), checkpoint.ID, total)), nil
+	}
+	return
+}
+
+// clients returns the source and target clients of a copy
+func (c copyTool) clients(req model.CopyCollectionRequest) (source *mongo.Client, target *mongo.Client, err error) {
+	source, err = connections.get(req.SourceConnection, c.connections)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err = connections.get(req.TargetConnection, c.connections)
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, target, nil
+}
+
+// start runs a copy in background, the copy outlives the tool call, false when the copy is already running
+func (c copyTool) start(checkpoint copyCheckpoint, source *mongo.Client, target *mongo.Client) bool {
+	if !copyRuns.start(checkpoint.ID) {
+		return false
+	}
+	log.Printf("Copy %s started from %s to %s after _id %v", checkpoint.ID, checkpoint.source(), checkpoint.target(), checkpoint.LastID)
+
+	go func() {
+		defer copyRuns.finish(checkpoint.ID)
+		ctx := context.Background()
+		checkpoints := client.DB.Collection(copyCheckpointsCollection)
+
+		// saved once the run is claimed, so a resume doesn't overwrite the checkpoint of a copy still running
+		_, err := checkpoints.UpdateOne(ctx, bson.M{"_id": checkpoint.ID}, bson.M{
+			"$set":   bson.M{"status": checkpoint.Status, "copied": checkpoint.Copied, "updated": time.Now()},
+			"$unset": bson.M{"error": ""},
+		})
+		if err != nil {
+			log.Printf("Copy %s: save checkpoint failed: %v", checkpoint.ID, err)
+		}
+
+		err = runCopy(ctx, &checkpoint, source, target)
+		update := bson.M{"updated": time.Now(), "copied": checkpoint.Copied, "indexes": checkpoint.Indexes}
+		if err != nil {
+			log.Printf("Copy %s failed: %v", checkpoint.ID, err)
+			update["status"] = copyStatusFailed
+			update["error"] = err.Error()
+		} else {
+			log.Printf("Copy %s done, %d documents copied", checkpoint.ID, checkpoint.Copied)
+			update["status"] = copyStatusDone
+			update["finished"] = time.Now()
+		}
+		if _, err = checkpoints.UpdateOne(ctx, bson.M{"_id": checkpoint.ID}, bson.M{"$set": update}); err != nil {
+			log.Printf("Copy %s: save checkpoint failed: %v", checkpoint.ID, err)
+		}
+	}()
+	return true
+}
+
+// runCopy copies the documents after the last checkpointed _id in batches, then the indexes and the validator
+func runCopy(ctx context.Context, checkpoint *copyCheckpoint, source *mongo.Client, target *mongo.Client) error {
+	req := checkpoint.Request
+	sourceDB := source.Database(req.SourceDatabase)
+	targetDB := target.Database(req.TargetDatabase)
+	checkpoints := client.DB.Collection(copyCheckpointsCollection)
+
+	info, err := databaseCollectionInfo(ctx, sourceDB, req.Collection)
+	if err != nil {
+		return err
+	}
+	if err = targetDB.CreateCollection(ctx, req.Target); err != nil && !isNamespaceExists(err) {
+		return err
+	}
+
+	transform, err := parsePipeline(req.Pipeline)
+	if err != nil {
+		return err
+	}
+	var filter interface{}
+	if filter, err = parseDocument(checkpoint.Filter); err != nil {
+		return err
+	}
+	if checkpoint.LastID != nil {
+		filter = bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: checkpoint.LastID}}}}}}}
+	}
+	pipeline := append(mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}, trackSourceID(transform)...)
+
+	cur, err := sourceDB.Collection(req.Collection).Aggregate(ctx, pipeline,
+		options.Aggregate().SetBatchSize(int32(req.BatchSize)).SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	targetColl := targetDB.Collection(req.Target)
+	var models []mongo.WriteModel
+	var lastID interface{}
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		// replacing by _id makes a batch safe to write again after an interruption, only the upserted documents are new
+		result, err := targetColl.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+		checkpoint.Copied += result.UpsertedCount
+		checkpoint.LastID = lastID
+		models = models[:0]
+		_, err = checkpoints.UpdateOne(ctx, bson.M{"_id": checkpoint.ID}, bson.M{"$set": bson.M{
+			"lastId":  checkpoint.LastID,
+			"copied":  checkpoint.Copied,
+			"updated": time.Now(),
+		}})
+		return err
+	}
+
+	for cur.Next(ctx) {
+		var doc bson.D
+		if err = cur.Decode(&doc); err != nil {
+			return err
+		}
+		sourceID, ok := lookup(doc, copySourceIDField)
+		if !ok {
+			return fmt.Errorf("the transform pipeline removed the %s field of a document after _id %v", copySourceIDField, lastID)
+		}
+		doc = slices.DeleteFunc(doc, func(e bson.E) bool { return e.Key == copySourceIDField })
+		id, ok := lookup(doc, "_id")
+		if !ok {
+			return fmt.Errorf("the transform pipeline removed the _id of the document with source _id %v", sourceID)
+		}
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.D{{Key: "_id", Value: id}}).SetReplacement(doc).SetUpsert(true))
+		lastID = sourceID
+		if len(models) >= req.BatchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err = cur.Err(); err != nil {
+		return err
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+
+	// views have neither indexes nor validator
+	if info.Type == "view" {
+		return nil
+	}
+	if req.CopyIndexes {
+		if checkpoint.Indexes, err = copyIndexes(ctx, sourceDB.Collection(req.Collection), targetDB, req.Target); err != nil {
+			return fmt.Errorf("copy indexes failed: %w", err)
+		}
+	}
+	if req.CopyValidator && len(info.Options.Validator) > 0 {
+		command := bson.D{
+			{Key: "collMod", Value: req.Target},
+			{Key: "validator", Value: info.Options.Validator},
+			{Key: "validationLevel", Value: info.Options.ValidationLevel},
+			{Key: "validationAction", Value: info.Options.ValidationAction},
+		}
+		if err = targetDB.RunCommand(ctx, command).Err(); err != nil {
+			return fmt.Errorf("copy validator failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyIndexes creates the indexes of a collection, except _id, on the target collection and returns their names
+func copyIndexes(ctx context.Context, source *mongo.Collection, targetDB *mongo.Database, target string) ([]string, error) {
+	cur, err := source.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var indexes []bson.D
+	if err = cur.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+	var specs []bson.D
+	var names []string
+	for _, index := range indexes {
+		name, _ := lookup(index, "name")
+		if name == "_id_" {
+			continue
+		}
+		var spec bson.D
+		for _, e := range index {
+			if e.Key != "v" && e.Key != "ns" {
+				spec = append(spec, e)
+			}
+		}
+		specs = append(specs, spec)
+		names = append(names, fmt.Sprint(name))
+	}
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	command := bson.D{{Key: "createIndexes", Value: target}, {Key: "indexes", Value: specs}}
+	return names, targetDB.RunCommand(ctx, command).Err()
+}
+
+// isNamespaceExists reports a create command failing because the collection already exists
+func isNamespaceExists(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && commandErr.Code == 48
+}
+
+// CopyProgress report the progress of copies
+func (c copyTool) CopyProgress() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CopyProgress",
+		mcp.WithDescription("Report the progress of a copy started by CopyCollection, or of the 20 latest copies"),
+		mcp.WithString("copy_id",
+			mcp.Description("copy_id returned by CopyCollection"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CopyProgressRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		filter := bson.M{}
+		if req.CopyID != "" {
+			filter["_id"] = req.CopyID
+		}
+		opts := options.Find().SetSort(bson.D{{Key: "started", Value: -1}}).SetLimit(20)
+		cur, err := client.DB.Collection(copyCheckpointsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var checkpoints []copyCheckpoint
+		if err = cur.All(ctx, &checkpoints); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(checkpoints) == 0 {
+			if req.CopyID != "" {
+				return mcp.NewToolResultText(fmt.Sprintf("copy %s not found", req.CopyID)), nil
+			}
+			return mcp.NewToolResultText("No copy found"), nil
+		}
+
+		var lines []string
+		for _, checkpoint := range checkpoints {
+			status := checkpoint.Status
+			// a copy left running by a previous process was interrupted
+			if status == copyStatusRunning && !copyRuns.running(checkpoint.ID) {
+				status = copyStatusInterrupted
+			}
+			line := fmt.Sprintf("Copy %s from %s to %s, status: %s, copied %d of %d documents",
+				checkpoint.ID, checkpoint.source(), checkpoint.target(), status, checkpoint.Copied, checkpoint.Total)
+			if checkpoint.Total > 0 {
+				line += fmt.Sprintf(" (%.1f%%)", float64(checkpoint.Copied)/float64(checkpoint.Total)*100)
+			}
+			line += fmt.Sprintf(", started: %s, updated: %s", checkpoint.Started.Format(time.RFC3339), checkpoint.Updated.Format(time.RFC3339))
+			if len(checkpoint.Indexes) > 0 {
+				line += ", indexes: " + strings.Join(checkpoint.Indexes, ", ")
+			}
+			if checkpoint.Error != "" {
+				line += ", error: " + checkpoint.Error
+			}
+			if status == copyStatusFailed || status == copyStatusInterrupted {
+				line += fmt.Sprintf(", resume with resume_id %s", checkpoint.ID)
+			}
+			lines = append(lines, line)
+		}
+		return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+	}
+	return
+}
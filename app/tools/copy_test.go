@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"mcp/app/client"
+)
+
+func TestValidateTransform(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline string
+		wantErr  string
+	}{
+		{name: "empty", pipeline: `[]`},
+		{name: "per document stages", pipeline: `[{"$match": {"a": 1}}, {"$set": {"email": "x"}}, {"$unset": "ssn"}, ` +
+			`{"$project": {"name": 1}}, {"$replaceWith": "$$ROOT"}, {"$lookup": {"from": "b", "localField": "b", "foreignField": "_id", "as": "b"}}]`},
+		{name: "group", pipeline: `[{"$group": {"_id": "$a"}}]`, wantErr: "stage 0: $group is not allowed"},
+		{name: "sort", pipeline: `[{"$set": {"a": 1}}, {"$sort": {"a": 1}}]`, wantErr: "stage 1: $sort is not allowed"},
+		{name: "limit", pipeline: `[{"$limit": 10}]`, wantErr: "$limit is not allowed"},
+		{name: "out", pipeline: `[{"$out": "other"}]`, wantErr: "$out is not allowed"},
+		{name: "two operators", pipeline: `[{"$set": {"a": 1}, "$unset": "b"}]`, wantErr: "exactly one operator"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := parsePipeline(tt.pipeline)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = validateTransform(pipeline)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsExclusionProjection(t *testing.T) {
+	tests := []struct {
+		projection string
+		want       bool
+	}{
+		{`{"ssn": 0}`, true},
+		{`{"ssn": false, "_id": 0}`, true},
+		{`{"_id": 0}`, true},
+		{`{"name": 1}`, false},
+		{`{"name": true, "_id": 0}`, false},
+		{`{"full": {"$concat": ["$first", "$last"]}}`, false},
+		{`{"label": "constant"}`, false},
+		{`{"address": {"zip": 0}}`, true},
+		{`{"address": {"zip": 0, "geo": {"lat": false}}, "_id": 0}`, true},
+		{`{"address": {"zip": 1}}`, false},
+		{`{"address": {"_id": 1}}`, false},
+		{`{"address": {"$ifNull": ["$address", {}]}}`, false},
+	}
+	for _, tt := range tests {
+		projection, err := parseDocument(tt.projection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isExclusionProjection(projection); got != tt.want {
+			t.Errorf("isExclusionProjection(%s) = %t, want %t", tt.projection, got, tt.want)
+		}
+	}
+}
+
+func TestTrackSourceID(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		want      string
+	}{
+		{
+			name:      "empty",
+			transform: `[]`,
+			want:      `[{"$set":{"_copySourceId":"$_id"}}]`,
+		},
+		{
+			name:      "stages keeping the fields",
+			transform: `[{"$set": {"email": "x"}}, {"$project": {"ssn": 0}}]`,
+			want:      `[{"$set":{"_copySourceId":"$_id"}},{"$set":{"email":"x"}},{"$project":{"ssn":0}}]`,
+		},
+		{
+			name:      "inclusion projection",
+			transform: `[{"$project": {"name": 1, "_id": {"$toString": "$_id"}}}]`,
+			want: `[{"$set":{"_copySourceId":"$_id"}},` +
+				`{"$project":{"name":1,"_id":{"$toString":"$_id"},"_copySourceId":1}}]`,
+		},
+		{
+			name:      "replaceWith",
+			transform: `[{"$replaceWith": "$profile"}]`,
+			want: `[{"$set":{"_copySourceId":"$_id"}},` +
+				`{"$replaceWith":{"$mergeObjects":["$profile",{"_copySourceId":"$_copySourceId"}]}}]`,
+		},
+		{
+			name:      "replaceRoot",
+			transform: `[{"$replaceRoot": {"newRoot": {"_id": "$sku", "name": "$name"}}}]`,
+			want: `[{"$set":{"_copySourceId":"$_id"}},` +
+				`{"$replaceRoot":{"newRoot":{"$mergeObjects":[{"_id":"$sku","name":"$name"},{"_copySourceId":"$_copySourceId"}]}}}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transform, err := parsePipeline(tt.transform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := bson.MarshalExtJSON(bson.D{{Key: "p", Value: trackSourceID(transform)}}, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := `{"p":` + tt.want + `}`
+			if string(got) != want {
+				t.Errorf("trackSourceID =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCopyCollectionValidation(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	_, handler := NewCopyTool(nil).CopyCollection()
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      string
+	}{
+		{
+			name:      "no collection",
+			arguments: map[string]interface{}{},
+			want:      "collection is required",
+		},
+		{
+			name:      "same collection",
+			arguments: map[string]interface{}{"collection": "orders"},
+			want:      "source and target are the same collection",
+		},
+		{
+			name:      "checkpoints as source",
+			arguments: map[string]interface{}{"collection": copyCheckpointsCollection, "target": "copy"},
+			want:      "can't be copied",
+		},
+		{
+			name:      "checkpoints as target",
+			arguments: map[string]interface{}{"collection": "orders", "target": copyCheckpointsCollection},
+			want:      "can't be copied",
+		},
+		{
+			name:      "transform stage",
+			arguments: map[string]interface{}{"collection": "orders", "target": "copy", "pipeline": `[{"$group": {"_id": null}}]`},
+			want:      "$group is not allowed",
+		},
+		{
+			name:      "unknown connection",
+			arguments: map[string]interface{}{"collection": "orders", "target_connection": "scratch"},
+			want:      "connection scratch is not configured",
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mockDB(mt)
+			text, err := callTool(t, handler, tt.arguments)
+			if err != nil || !strings.Contains(text, tt.want) {
+				t.Errorf("result = %q, %v, want %q", text, err, tt.want)
+			}
+			if started := mt.GetAllStartedEvents(); len(started) != 0 {
+				t.Errorf("%d commands sent, want none", len(started))
+			}
+		})
+	}
+}
+
+func TestCopyCollectionResumeRunning(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	_, handler := NewCopyTool(nil).CopyCollection()
+
+	mt.Run("still running", func(mt *mtest.T) {
+		mockDB(mt)
+		mongoClient := client.MongoClient
+		client.MongoClient = mt.Client
+		mt.Cleanup(func() { client.MongoClient = mongoClient })
+
+		id := "65a1b2c3d4e5f60718293a4b"
+		if !copyRuns.start(id) {
+			t.Fatalf("copy %s already running", id)
+		}
+		defer copyRuns.finish(id)
+
+		checkpoint := bson.D{
+			{Key: "_id", Value: id},
+			{Key: "request", Value: bson.D{
+				{Key: "sourceDatabase", Value: "test"}, {Key: "collection", Value: "orders"},
+				{Key: "targetDatabase", Value: "test"}, {Key: "target", Value: "copy"},
+			}},
+			{Key: "status", Value: copyStatusFailed},
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+copyCheckpointsCollection, mtest.FirstBatch, checkpoint),
+			mtest.CreateCursorResponse(0, "test.copy", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(3)}}),
+		)
+
+		text, err := callTool(t, handler, map[string]interface{}{"resume_id": id})
+		if err != nil || text != "copy "+id+" is still running" {
+			t.Errorf("result = %q, %v, want still running", text, err)
+		}
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName == "update" {
+				t.Errorf("checkpoint of a running copy updated: %s", event.Command)
+			}
+		}
+	})
+}
@@ -7,10 +7,13 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"mcp/app/client"
 	"mcp/app/model"
+	"strings"
+	"time"
 )
 
 type DocumentTool interface {
@@ -24,6 +27,12 @@ type DocumentTool interface {
 	DeleteOne() (mcp.Tool, server.ToolHandlerFunc)
 	// UpdateOne insert one document in collection
 	UpdateOne() (mcp.Tool, server.ToolHandlerFunc)
+	// Distinct get distinct values of a field in collection
+	Distinct() (mcp.Tool, server.ToolHandlerFunc)
+	// VectorSearch find the documents most similar to a query vector
+	VectorSearch() (mcp.Tool, server.ToolHandlerFunc)
+	// TextSearch find documents matching words or phrases with a text index
+	TextSearch() (mcp.Tool, server.ToolHandlerFunc)
 }
 
 type documentTool struct{}
@@ -57,6 +66,9 @@ func (c documentTool) Find() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 			mcp.Description("MongoDB projection filter"),
 			mcp.DefaultString("{}"),
 		),
+		mcp.WithString("transaction_id",
+			mcp.Description("Run inside the transaction returned by StartTransaction"),
+		),
 	)
 	// handler
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -68,6 +80,12 @@ func (c documentTool) Find() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 
 		log.Printf(fmt.Sprintf("Find document in collection: %s, filter: %s, limit: %d", req.Collection, req.Filter, req.Limit))
 
+		ctx, release, err := transactionContext(ctx, req.TransactionID)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		defer release()
+
 		cur, err := client.DB.Collection(req.Collection).Find(ctx, req.Filter, &options.FindOptions{
 			Limit:      &req.Limit,
 			Projection: req.Projection,
@@ -102,7 +120,8 @@ func (c documentTool) Count() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	// MCP Tool
 	tool = mcp.NewTool(
 		"Count",
-		mcp.WithDescription("Count documents in a collection using MongoDB query syntax"),
+		mcp.WithDescription("Count documents in a collection using MongoDB query syntax. "+
+			"Without a filter the count is estimated from collection metadata unless exact is set"),
 		mcp.WithString("collection",
 			mcp.Required(),
 			mcp.Description("Collection name to query"),
@@ -115,6 +134,18 @@ func (c documentTool) Count() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 			mcp.Description("MongoDB projection filter"),
 			mcp.DefaultString("{}"),
 		),
+		mcp.WithBoolean("exact",
+			mcp.Description("true to always count matching documents, false to always use the estimated count "+
+				"(only without filter), unset to pick the fastest strategy"),
+		),
+		mcp.WithNumber("max_time_ms",
+			mcp.Description("Maximum time in milliseconds the count may run, 0 for no limit"),
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+		),
+		mcp.WithString("transaction_id",
+			mcp.Description("Run inside the transaction returned by StartTransaction"),
+		),
 	)
 	// handler
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -126,12 +157,46 @@ func (c documentTool) Count() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 
 		log.Printf(fmt.Sprintf("Count document in collection: %s, filter: %s", req.Collection, req.Filter))
 
-		count, err := client.DB.Collection(req.Collection).CountDocuments(ctx, req.Filter)
+		// the estimated count reads collection metadata, it can't apply a filter or run in a transaction
+		estimated := len(req.Filter) == 0 && req.TransactionID == ""
+		if req.Exact != nil {
+			if !*req.Exact && !estimated {
+				return mcp.NewToolResultText("Estimated count is only available without filter and transaction"), nil
+			}
+			estimated = !*req.Exact
+		}
+
+		ctx, release, err := transactionContext(ctx, req.TransactionID)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		defer release()
+
+		var count int64
+		var strategy string
+		if estimated {
+			strategy = "estimated"
+			opts := options.EstimatedDocumentCount()
+			if req.MaxTimeMS > 0 {
+				opts.SetMaxTime(time.Duration(req.MaxTimeMS) * time.Millisecond)
+			}
+			count, err = client.DB.Collection(req.Collection).EstimatedDocumentCount(ctx, opts)
+		} else {
+			strategy = "exact"
+			opts := options.Count()
+			if req.MaxTimeMS > 0 {
+				opts.SetMaxTime(time.Duration(req.MaxTimeMS) * time.Millisecond)
+			}
+			if req.Filter == nil {
+				req.Filter = map[string]interface{}{}
+			}
+			count, err = client.DB.Collection(req.Collection).CountDocuments(ctx, req.Filter, opts)
+		}
 		if err != nil {
 			return mcp.NewToolResultText(err.Error()), err
 		}
-		log.Printf(fmt.Sprintf("Count documents success, count: %d", count))
-		return mcp.NewToolResultText(fmt.Sprintf("Count documents success, count: %d", count)), nil
+		log.Printf(fmt.Sprintf("Count documents success, count: %d, strategy: %s", count, strategy))
+		return mcp.NewToolResultText(fmt.Sprintf("Count documents success, count: %d, strategy: %s", count, strategy)), nil
 	}
 	return
 }
@@ -151,6 +216,9 @@ func (c documentTool) InsertOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 			mcp.Description("document to insert"),
 			mcp.DefaultString("{}"),
 		),
+		mcp.WithString("transaction_id",
+			mcp.Description("Run inside the transaction returned by StartTransaction"),
+		),
 	)
 	// handler
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -169,6 +237,12 @@ func (c documentTool) InsertOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 		if err != nil {
 			return mcp.NewToolResultText("Parse document failed"), err
 		}
+		ctx, release, err := transactionContext(ctx, req.TransactionID)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		defer release()
+
 		res, err := client.DB.Collection(req.Collection).InsertOne(ctx, document)
 		if err != nil {
 			return mcp.NewToolResultText(err.Error()), err
@@ -196,6 +270,9 @@ func (c documentTool) DeleteOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 			mcp.Required(),
 			mcp.Description("Filter to identify document"),
 		),
+		mcp.WithString("transaction_id",
+			mcp.Description("Run inside the transaction returned by StartTransaction"),
+		),
 	)
 	// handler
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -207,6 +284,12 @@ func (c documentTool) DeleteOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 
 		log.Printf(fmt.Sprintf("Delete document in collection: %s, filter: %s", req.Collection, req.Filter))
 
+		ctx, release, err := transactionContext(ctx, req.TransactionID)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		defer release()
+
 		res, err := client.DB.Collection(req.Collection).DeleteOne(ctx, req.Filter)
 		if err != nil {
 			return mcp.NewToolResultText(err.Error()), err
@@ -237,6 +320,9 @@ func (c documentTool) UpdateOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 			mcp.Required(),
 			mcp.Description("Update operations to apply"),
 		),
+		mcp.WithString("transaction_id",
+			mcp.Description("Run inside the transaction returned by StartTransaction"),
+		),
 	)
 	// handler
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -249,6 +335,12 @@ func (c documentTool) UpdateOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 
 		log.Printf(fmt.Sprintf("Update document in collection: %s, filter: %s", req.Collection, req.Filter))
 
+		ctx, release, err := transactionContext(ctx, req.TransactionID)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		defer release()
+
 		res, err := client.DB.Collection(req.Collection).UpdateOne(ctx, req.Filter, req.Update)
 		if err != nil {
 			return mcp.NewToolResultText(err.Error()), err
@@ -267,3 +359,119 @@ func (c documentTool) UpdateOne() (tool mcp.Tool, handler server.ToolHandlerFunc
 	}
 	return
 }
+
+// Distinct get distinct values of a field in collection
+func (c documentTool) Distinct() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	// MCP Tool
+	tool = mcp.NewTool(
+		"Distinct",
+		mcp.WithDescription("Get the distinct values of a field in a collection, e.g. to learn the allowed values before filtering"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to query"),
+		),
+		mcp.WithString("field",
+			mcp.Required(),
+			mcp.Description("Field path to get distinct values of, dotted paths are supported"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("MongoDB query filter"),
+			mcp.DefaultString("{}"),
+		),
+		mcp.WithObject("collation",
+			mcp.Description("Collation used to compare strings (e.g., { locale: 'en', strength: 2 } for case insensitive)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of distinct values to return"),
+			mcp.DefaultNumber(100),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+		mcp.WithString("transaction_id",
+			mcp.Description("Run inside the transaction returned by StartTransaction"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.DistinctDocumentRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Limit <= 0 {
+			req.Limit = 100
+		}
+
+		log.Printf("Distinct field %s in collection: %s, filter: %v", req.Field, req.Collection, req.Filter)
+
+		ctx, release, err := transactionContext(ctx, req.TransactionID)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		defer release()
+
+		// group instead of the distinct command, which fails once the values exceed 16MB
+		if req.Filter == nil {
+			req.Filter = map[string]interface{}{}
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: req.Filter}},
+			{{Key: "$match", Value: bson.M{req.Field: bson.M{"$exists": true}}}},
+		}
+		// unwind every segment of the path so values inside arrays of subdocuments are reached,
+		// empty arrays are dropped like the distinct command does
+		segments := strings.Split(req.Field, ".")
+		for i := range segments {
+			path := "$" + strings.Join(segments[:i+1], ".")
+			pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: path}})
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$group", Value: bson.M{"_id": "$" + req.Field}}},
+			bson.D{{Key: "$facet", Value: bson.M{
+				"total":  bson.A{bson.M{"$count": "count"}},
+				"values": bson.A{bson.M{"$sort": bson.M{"_id": 1}}, bson.M{"$limit": req.Limit}},
+			}}},
+		)
+		opts := options.Aggregate().SetCollation(req.Collation)
+		if req.TransactionID == "" {
+			opts.SetAllowDiskUse(true)
+		}
+		cur, err := client.DB.Collection(req.Collection).Aggregate(ctx, pipeline, opts)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var results []struct {
+			Total []struct {
+				Count int64 `bson:"count"`
+			} `bson:"total"`
+			Values []struct {
+				Value interface{} `bson:"_id"`
+			} `bson:"values"`
+		}
+		if err = cur.All(ctx, &results); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(results) == 0 || len(results[0].Total) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No values found for field %s", req.Field)), nil
+		}
+
+		total := results[0].Total[0].Count
+		values := bson.A{}
+		for _, value := range results[0].Values {
+			values = append(values, value.Value)
+		}
+		data, err := bson.MarshalExtJSON(bson.D{{Key: "values", Value: values}}, false, false)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		result := fmt.Sprintf("Distinct values of %s, total: %d", req.Field, total)
+		if total > int64(len(values)) {
+			result += fmt.Sprintf(", truncated to the first %d, narrow the filter to see the rest", len(values))
+		}
+		return mcp.NewToolResultText(result + "\n" + string(data)), nil
+	}
+	return
+}
@@ -0,0 +1,376 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"sort"
+	"strings"
+)
+
+// scoreField holds the relevance score added to the documents returned by the search tools
+const scoreField = "_score"
+
+// storedEmbedding reads the embedding at path of the first document matching filter,
+// kept as the raw array or binData vector so it's passed to $vectorSearch unchanged
+func storedEmbedding(ctx context.Context, collection string, path string, filter map[string]interface{}) (bson.RawValue, error) {
+	var doc bson.Raw
+	err := client.DB.Collection(collection).FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{path: 1})).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return bson.RawValue{}, fmt.Errorf("no document matches query_document")
+	}
+	if err != nil {
+		return bson.RawValue{}, err
+	}
+	value, err := doc.LookupErr(strings.Split(path, ".")...)
+	if err != nil {
+		return bson.RawValue{}, fmt.Errorf("the document matching query_document has no %s field", path)
+	}
+	if value.Type != bsontype.Array && value.Type != bsontype.Binary {
+		return bson.RawValue{}, fmt.Errorf("field %s of the document matching query_document is a %s, not an embedding", path, value.Type)
+	}
+	return value, nil
+}
+
+// VectorSearch find the documents most similar to a query vector
+func (c documentTool) VectorSearch() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	// MCP Tool
+	tool = mcp.NewTool(
+		"VectorSearch",
+		mcp.WithDescription("Find the documents most similar to a query vector with an Atlas Vector Search index. "+
+			"Documents are returned with their similarity in the "+scoreField+" field and without the embedding field"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to query"),
+		),
+		mcp.WithString("index",
+			mcp.Required(),
+			mcp.Description("Name of the vectorSearch index"),
+		),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Indexed field holding the embeddings"),
+		),
+		mcp.WithArray("query_vector",
+			mcp.Description("Query embedding, with the number of dimensions of the index"),
+			mcp.Items(map[string]interface{}{"type": "number"}),
+		),
+		mcp.WithObject("query_document",
+			mcp.Description("Filter of a stored document whose embedding is used as query vector, "+
+				"to find documents similar to it, instead of query_vector"),
+		),
+		mcp.WithNumber("num_candidates",
+			mcp.Description("Number of nearest neighbors considered, higher is more accurate and slower, defaults to 10 times the limit"),
+			mcp.Min(1),
+			mcp.Max(10000),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Number of documents to return"),
+			mcp.DefaultNumber(10),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("Pre-filter on the filter fields of the index, using MongoDB query syntax"),
+		),
+		mcp.WithArray("exclude_fields",
+			mcp.Description("Other large fields to strip from the returned documents"),
+			mcp.Items(map[string]interface{}{"type": "string"}),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.VectorSearchRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Limit <= 0 {
+			req.Limit = 10
+		}
+		if req.NumCandidates <= 0 {
+			req.NumCandidates = min(req.Limit*10, 10000)
+		}
+		if req.NumCandidates < req.Limit {
+			return mcp.NewToolResultText("num_candidates must be greater than or equal to limit"), nil
+		}
+
+		var queryVector interface{}
+		switch {
+		case len(req.QueryVector) > 0 && req.QueryDocument != nil:
+			return mcp.NewToolResultText("Pass either query_vector or query_document, not both"), nil
+		case len(req.QueryVector) > 0:
+			queryVector = req.QueryVector
+		case req.QueryDocument != nil:
+			embedding, err := storedEmbedding(ctx, req.Collection, req.Path, req.QueryDocument)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			queryVector = embedding
+		default:
+			return mcp.NewToolResultText("query_vector or query_document is required"), nil
+		}
+
+		log.Printf("Vector search in collection: %s, index: %s, path: %s, limit: %d, filter: %v",
+			req.Collection, req.Index, req.Path, req.Limit, req.Filter)
+
+		search := bson.D{
+			{Key: "index", Value: req.Index},
+			{Key: "path", Value: req.Path},
+			{Key: "queryVector", Value: queryVector},
+			{Key: "numCandidates", Value: req.NumCandidates},
+			{Key: "limit", Value: req.Limit},
+		}
+		if len(req.Filter) > 0 {
+			search = append(search, bson.E{Key: "filter", Value: req.Filter})
+		}
+		exclude := bson.D{{Key: req.Path, Value: 0}}
+		for _, field := range req.ExcludeFields {
+			if field != req.Path {
+				exclude = append(exclude, bson.E{Key: field, Value: 0})
+			}
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$vectorSearch", Value: search}},
+			{{Key: "$addFields", Value: bson.M{scoreField: bson.M{"$meta": "vectorSearchScore"}}}},
+			{{Key: "$project", Value: exclude}},
+		}
+		cur, err := client.DB.Collection(req.Collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var documents []bson.D
+		if err = cur.All(ctx, &documents); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(documents) == 0 {
+			return mcp.NewToolResultText("No documents found, check the index is queryable with ListSearchIndexes"), nil
+		}
+		result, err := formatDocuments(documents)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// stringField describes a field holding strings in sampled documents
+type stringField struct {
+	Path   string
+	Count  int
+	Length int
+}
+
+// collectStringFields adds the string fields of doc, arrays of strings included, to fields
+func collectStringFields(doc bson.D, prefix string, fields map[string]*stringField) {
+	add := func(path string, value string) {
+		field, ok := fields[path]
+		if !ok {
+			field = &stringField{Path: path}
+			fields[path] = field
+		}
+		field.Count++
+		field.Length += len(value)
+	}
+	for _, e := range doc {
+		path := prefix + e.Key
+		switch value := e.Value.(type) {
+		case string:
+			add(path, value)
+		case bson.D:
+			collectStringFields(value, path+".", fields)
+		case bson.A:
+			for _, item := range value {
+				if s, ok := item.(string); ok {
+					add(path, s)
+				}
+			}
+		}
+	}
+}
+
+// textCandidateFields samples documents of the collection and returns their string fields, longest first
+func textCandidateFields(ctx context.Context, collection string) ([]stringField, error) {
+	cur, err := client.DB.Collection(collection).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sample", Value: bson.M{"size": 100}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	fields := map[string]*stringField{}
+	for cur.Next(ctx) {
+		var doc bson.D
+		if err = cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		collectStringFields(doc, "", fields)
+	}
+	if err = cur.Err(); err != nil {
+		return nil, err
+	}
+
+	var candidates []stringField
+	for _, field := range fields {
+		candidates = append(candidates, *field)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Length/candidates[i].Count > candidates[j].Length/candidates[j].Count
+	})
+	return candidates, nil
+}
+
+// hasTextIndex reports whether the collection has a text index, $text fails without one
+func hasTextIndex(ctx context.Context, collection string) (bool, error) {
+	cur, err := client.DB.Collection(collection).Indexes().List(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cur.Close(ctx)
+
+	var indexes []struct {
+		Key bson.D `bson:"key"`
+	}
+	if err = cur.All(ctx, &indexes); err != nil {
+		return false, err
+	}
+	for _, index := range indexes {
+		if value, ok := lookup(index.Key, "_fts"); ok && value == "text" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TextSearch find documents matching words or phrases with a text index
+func (c documentTool) TextSearch() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	// MCP Tool
+	tool = mcp.NewTool(
+		"TextSearch",
+		mcp.WithDescription("Full-text search of words and phrases with the text index of a collection, "+
+			"prefer it to regex filters on large string fields. Documents are sorted by relevance, "+
+			"returned in the "+scoreField+" field"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to query"),
+		),
+		mcp.WithString("search",
+			mcp.Required(),
+			mcp.Description("Words to search, any of them matches. Quote phrases (e.g., \"\\\"coffee shop\\\"\") "+
+				"and prefix words with - to exclude them"),
+		),
+		mcp.WithString("language",
+			mcp.Description("Language of the stemming and stop words, defaults to the language of the index, none disables them"),
+		),
+		mcp.WithBoolean("case_sensitive",
+			mcp.Description("Match the case of the words"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("diacritic_sensitive",
+			mcp.Description("Match the diacritics of the words (e.g., é doesn't match e)"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("Additional MongoDB query filter"),
+		),
+		mcp.WithObject("projection",
+			mcp.Description("MongoDB projection filter"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Number of documents to return"),
+			mcp.DefaultNumber(20),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.TextSearchRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Limit <= 0 {
+			req.Limit = 20
+		}
+
+		log.Printf("Text search in collection: %s, search: %s, filter: %v", req.Collection, req.Search, req.Filter)
+
+		indexed, err := hasTextIndex(ctx, req.Collection)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if !indexed {
+			candidates, err := textCandidateFields(ctx, req.Collection)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			result := fmt.Sprintf("Collection %s has no text index. ", req.Collection)
+			if len(candidates) == 0 {
+				return mcp.NewToolResultText(result + "No string fields found in sampled documents"), nil
+			}
+			result += "Create one with CreateIndex (e.g., keys {\"" + candidates[0].Path + "\": \"text\"}), " +
+				"string fields of sampled documents, longest first:\n"
+			for _, field := range candidates {
+				result += fmt.Sprintf("  %s: in %d values, average length %d\n", field.Path, field.Count, field.Length/field.Count)
+			}
+			return mcp.NewToolResultText(result), nil
+		}
+
+		text := bson.D{
+			{Key: "$search", Value: req.Search},
+			{Key: "$caseSensitive", Value: req.CaseSensitive},
+			{Key: "$diacriticSensitive", Value: req.DiacriticSensitive},
+		}
+		if req.Language != "" {
+			text = append(text, bson.E{Key: "$language", Value: req.Language})
+		}
+		filter := bson.D{{Key: "$text", Value: text}}
+		for key, value := range req.Filter {
+			filter = append(filter, bson.E{Key: key, Value: value})
+		}
+		projection := bson.D{}
+		for key, value := range req.Projection {
+			projection = append(projection, bson.E{Key: key, Value: value})
+		}
+		score := bson.M{"$meta": "textScore"}
+		projection = append(projection, bson.E{Key: scoreField, Value: score})
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(bson.D{{Key: scoreField, Value: score}}).
+			SetLimit(req.Limit)
+		cur, err := client.DB.Collection(req.Collection).Find(ctx, filter, opts)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var documents []bson.D
+		if err = cur.All(ctx, &documents); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(documents) == 0 {
+			return mcp.NewToolResultText("No documents found"), nil
+		}
+		result, err := formatDocuments(documents)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
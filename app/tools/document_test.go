@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestDistinctUnwindsPath(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	_, handler := NewDocumentTool().Distinct()
+
+	mt.Run("nested array", func(mt *mtest.T) {
+		mockDB(mt)
+		// orders like {"items": [{"category": "book"}, {"category": ["toy", "game"]}]} and {"items": []}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.orders", mtest.FirstBatch, bson.D{
+			{Key: "total", Value: bson.A{bson.D{{Key: "count", Value: int32(3)}}}},
+			{Key: "values", Value: bson.A{
+				bson.D{{Key: "_id", Value: "book"}},
+				bson.D{{Key: "_id", Value: "game"}},
+				bson.D{{Key: "_id", Value: "toy"}},
+			}},
+		}))
+
+		text, err := callTool(t, handler, map[string]interface{}{"collection": "orders", "field": "items.category"})
+		if err != nil {
+			t.Fatalf("Distinct failed: %v", err)
+		}
+		if want := `{"values":["book","game","toy"]}`; !strings.Contains(text, want) {
+			t.Errorf("result = %q, want %s", text, want)
+		}
+
+		var unwound []string
+		pipeline := mt.GetStartedEvent().Command.Lookup("pipeline").Array()
+		values, _ := pipeline.Values()
+		for _, value := range values {
+			unwind, err := value.Document().LookupErr("$unwind")
+			if err != nil {
+				continue
+			}
+			// a path without options keeps empty arrays from turning into a null value
+			if _, ok := unwind.StringValueOK(); !ok {
+				t.Errorf("$unwind = %v, want a plain path", unwind)
+				continue
+			}
+			unwound = append(unwound, unwind.StringValue())
+		}
+		if got, want := strings.Join(unwound, ","), "$items,$items.category"; got != want {
+			t.Errorf("unwound %s, want %s", got, want)
+		}
+	})
+
+	mt.Run("empty array", func(mt *mtest.T) {
+		mockDB(mt)
+		// only {"tags": []} matches, it has no values once unwound
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.orders", mtest.FirstBatch, bson.D{
+			{Key: "total", Value: bson.A{}},
+			{Key: "values", Value: bson.A{}},
+		}))
+
+		text, err := callTool(t, handler, map[string]interface{}{"collection": "orders", "field": "tags"})
+		if err != nil {
+			t.Fatalf("Distinct failed: %v", err)
+		}
+		if want := "No values found for field tags"; text != want {
+			t.Errorf("result = %q, want %q", text, want)
+		}
+		if strings.Contains(mt.GetStartedEvent().Command.String(), "preserveNullAndEmptyArrays") {
+			t.Errorf("pipeline preserves empty arrays: %s", mt.GetStartedEvent().Command)
+		}
+	})
+}
@@ -0,0 +1,439 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"io/fs"
+	"log"
+	"mcp/app/client"
+	"mcp/app/configs"
+	"mcp/app/model"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultExportDirectory = "exports"
+	exportResourcePrefix   = "export://"
+	// maxExportResourceBytes bounds the files read back through the export resource
+	maxExportResourceBytes = 10 * 1024 * 1024
+)
+
+// exportFormat is the file extension and MIME type of an export format
+type exportFormat struct {
+	Extension string
+	MIMEType  string
+}
+
+var exportFormats = map[string]exportFormat{
+	"jsonl":   {Extension: ".jsonl", MIMEType: "application/x-ndjson"},
+	"csv":     {Extension: ".csv", MIMEType: "text/csv"},
+	"extjson": {Extension: ".json", MIMEType: "application/json"},
+}
+
+// unsafeFilenameChars are replaced in the collection name of a default export filename
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// defaultExportFilename names an export after its collection, the time and a random suffix,
+// so exports started in the same second get their own file
+func defaultExportFilename(collection string, extension string) string {
+	name := strings.TrimLeft(unsafeFilenameChars.ReplaceAllString(collection, "_"), "._-")
+	if name == "" {
+		name = "export"
+	}
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%s-%x%s", name, time.Now().UTC().Format("20060102T150405"), suffix, extension)
+}
+
+// filenamePattern keeps the exported and imported files inside their directory
+var filenamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// directoryFile returns the path of a file of directory, refusing names escaping it
+func directoryFile(directory string, filename string) (string, error) {
+	if !filenamePattern.MatchString(filename) {
+		return "", fmt.Errorf("invalid filename %q, use letters, digits, '.', '_' and '-'", filename)
+	}
+	return filepath.Join(directory, filename), nil
+}
+
+type ExportTool interface {
+	// Export write query results to a file
+	Export() (mcp.Tool, server.ToolHandlerFunc)
+	// ExportResource read an exported file
+	ExportResource() (mcp.ResourceTemplate, server.ResourceTemplateHandlerFunc)
+}
+
+type exportTool struct {
+	Directory string
+}
+
+func NewExportTool(config configs.ExportConfig) ExportTool {
+	directory := config.Directory
+	if directory == "" {
+		directory = defaultExportDirectory
+	}
+	return &exportTool{Directory: directory}
+}
+
+// exportCursor runs the aggregation pipeline of the request, or its find when there is none
+func exportCursor(ctx context.Context, req model.ExportRequest) (*mongo.Cursor, error) {
+	coll := client.DB.Collection(req.Collection)
+	if req.Pipeline != "" {
+		pipeline, err := parsePipeline(req.Pipeline)
+		if err != nil {
+			return nil, err
+		}
+		if req.Limit > 0 {
+			pipeline = append(pipeline, bson.D{{Key: "$limit", Value: req.Limit}})
+		}
+		return coll.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	}
+
+	sort, err := parseDocument(req.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if req.Filter == nil {
+		req.Filter = map[string]interface{}{}
+	}
+	opts := options.Find().SetLimit(req.Limit)
+	if len(req.Projection) > 0 {
+		opts.SetProjection(req.Projection)
+	}
+	if len(sort) > 0 {
+		opts.SetSort(sort).SetAllowDiskUse(true)
+	}
+	return coll.Find(ctx, req.Filter, opts)
+}
+
+// writeJSONLines writes one relaxed Extended JSON document per line
+func writeJSONLines(ctx context.Context, cur *mongo.Cursor, w io.Writer) (int64, error) {
+	var rows int64
+	for cur.Next(ctx) {
+		data, err := bson.MarshalExtJSON(cur.Current, false, false)
+		if err != nil {
+			return rows, err
+		}
+		if _, err = fmt.Fprintf(w, "%s\n", data); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	return rows, cur.Err()
+}
+
+// writeExtJSON writes a JSON array of canonical Extended JSON documents, keeping every BSON type
+func writeExtJSON(ctx context.Context, cur *mongo.Cursor, w io.Writer) (int64, error) {
+	var rows int64
+	if _, err := io.WriteString(w, "["); err != nil {
+		return rows, err
+	}
+	for cur.Next(ctx) {
+		data, err := bson.MarshalExtJSON(cur.Current, true, false)
+		if err != nil {
+			return rows, err
+		}
+		separator := ",\n"
+		if rows == 0 {
+			separator = "\n"
+		}
+		if _, err = fmt.Fprintf(w, "%s%s", separator, data); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	if err := cur.Err(); err != nil {
+		return rows, err
+	}
+	_, err := io.WriteString(w, "\n]\n")
+	return rows, err
+}
+
+// flattenDocument visits the values of a document, with the dotted path of the fields of nested documents
+func flattenDocument(doc bson.Raw, prefix string, visit func(path string, value bson.RawValue)) {
+	elements, _ := doc.Elements()
+	for _, element := range elements {
+		path := prefix + element.Key()
+		value := element.Value()
+		if value.Type == bsontype.EmbeddedDocument {
+			flattenDocument(value.Document(), path+".", visit)
+			continue
+		}
+		visit(path, value)
+	}
+}
+
+// csvValue renders a value as a CSV cell, arrays and other composite values as Extended JSON
+func csvValue(value bson.RawValue) string {
+	switch value.Type {
+	case bsontype.String:
+		return value.StringValue()
+	case bsontype.ObjectID:
+		return value.ObjectID().Hex()
+	case bsontype.DateTime:
+		return value.Time().UTC().Format(time.RFC3339Nano)
+	case bsontype.Boolean:
+		return strconv.FormatBool(value.Boolean())
+	case bsontype.Int32:
+		return strconv.FormatInt(int64(value.Int32()), 10)
+	case bsontype.Int64:
+		return strconv.FormatInt(value.Int64(), 10)
+	case bsontype.Double:
+		return strconv.FormatFloat(value.Double(), 'f', -1, 64)
+	case bsontype.Decimal128:
+		return value.Decimal128().String()
+	case bsontype.Null, bsontype.Undefined:
+		return ""
+	default:
+		return value.String()
+	}
+}
+
+// writeCSV writes a header of the dotted paths of all the documents, then one row per document.
+// The documents are spooled to a temporary file, as the header is only known once all of them are read
+func writeCSV(ctx context.Context, cur *mongo.Cursor, w io.Writer, directory string) (int64, error) {
+	spool, err := os.CreateTemp(directory, ".export-*.bson")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var headers []string
+	columns := map[string]int{}
+	spoolWriter := bufio.NewWriter(spool)
+	for cur.Next(ctx) {
+		flattenDocument(cur.Current, "", func(path string, value bson.RawValue) {
+			if _, ok := columns[path]; !ok {
+				columns[path] = len(headers)
+				headers = append(headers, path)
+			}
+		})
+		if _, err = spoolWriter.Write(cur.Current); err != nil {
+			return 0, err
+		}
+	}
+	if err = cur.Err(); err != nil {
+		return 0, err
+	}
+	if err = spoolWriter.Flush(); err != nil {
+		return 0, err
+	}
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	writer := csv.NewWriter(w)
+	if err = writer.Write(headers); err != nil {
+		return 0, err
+	}
+	var rows int64
+	reader := bufio.NewReader(spool)
+	for {
+		doc, err := bson.NewFromIOReader(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		record := make([]string, len(headers))
+		flattenDocument(doc, "", func(path string, value bson.RawValue) {
+			record[columns[path]] = csvValue(value)
+		})
+		if err = writer.Write(record); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	writer.Flush()
+	return rows, writer.Error()
+}
+
+// Export write query results to a file
+func (e exportTool) Export() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"Export",
+		mcp.WithDescription("Stream the results of a find or an aggregation to a file of the export directory, "+
+			"for results too large for a message. The file is readable as the resource "+exportResourcePrefix+"{filename}"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to query"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("MongoDB query filter, ignored when a pipeline is given"),
+		),
+		mcp.WithObject("projection",
+			mcp.Description("MongoDB projection filter, ignored when a pipeline is given"),
+		),
+		mcp.WithString("sort",
+			mcp.Description("Sort as Extended JSON to keep the order of the keys (e.g., {\"createdAt\": -1})"),
+		),
+		mcp.WithString("pipeline",
+			mcp.Description("Aggregation pipeline as an Extended JSON array, exports its results instead of a find"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of documents to export, 0 exports all of them"),
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+		),
+		mcp.WithString("format",
+			mcp.Description("jsonl writes relaxed Extended JSON lines, csv flattens nested fields to dotted headers, "+
+				"extjson writes a canonical Extended JSON array keeping every BSON type"),
+			mcp.Enum("jsonl", "csv", "extjson"),
+			mcp.DefaultString("jsonl"),
+		),
+		mcp.WithString("filename",
+			mcp.Description("Name of the file, defaults to <collection>-<timestamp>-<random suffix> with the extension of the format"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace an existing file"),
+			mcp.DefaultBool(false),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ExportRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Format == "" {
+			req.Format = "jsonl"
+		}
+		format, ok := exportFormats[req.Format]
+		if !ok {
+			return mcp.NewToolResultText(fmt.Sprintf("unknown format %s, use jsonl, csv or extjson", req.Format)), nil
+		}
+		if req.Filename == "" {
+			req.Filename = defaultExportFilename(req.Collection, format.Extension)
+		}
+		path, err := directoryFile(e.Directory, req.Filename)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if _, err = os.Stat(path); err == nil && !req.Overwrite {
+			return mcp.NewToolResultText(fmt.Sprintf("File %s already exists, pass overwrite to replace it", req.Filename)), nil
+		}
+		if err = os.MkdirAll(e.Directory, 0o755); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		cur, err := exportCursor(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		log.Printf("Export collection %s to %s", req.Collection, path)
+
+		// write a temporary file renamed once complete, so a failed export leaves no partial file
+		file, err := os.CreateTemp(e.Directory, ".export-*"+format.Extension)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		writer := bufio.NewWriter(file)
+		var rows int64
+		switch req.Format {
+		case "csv":
+			rows, err = writeCSV(ctx, cur, writer, e.Directory)
+		case "extjson":
+			rows, err = writeExtJSON(ctx, cur, writer)
+		default:
+			rows, err = writeJSONLines(ctx, cur, writer)
+		}
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err == nil {
+			err = file.Close()
+		}
+		if err == nil && req.Overwrite {
+			err = os.Rename(file.Name(), path)
+		} else if err == nil {
+			// a link fails when the file exists, an export of the same name finished meanwhile is never replaced
+			err = os.Link(file.Name(), path)
+			if errors.Is(err, fs.ErrExist) {
+				return mcp.NewToolResultText(fmt.Sprintf("File %s already exists, pass overwrite to replace it", req.Filename)), nil
+			}
+		}
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("Export failed after %d documents: %v", rows, err)), err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		absolute, _ := filepath.Abs(path)
+		return mcp.NewToolResultText(fmt.Sprintf("Exported %d documents to %s, %d bytes, resource: %s%s",
+			rows, absolute, info.Size(), exportResourcePrefix, req.Filename)), nil
+	}
+	return
+}
+
+// ExportResource read an exported file
+func (e exportTool) ExportResource() (template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) {
+
+	// MCP Resource Template
+	template = mcp.NewResourceTemplate(
+		exportResourcePrefix+"{filename}",
+		"Exported file",
+		mcp.WithTemplateDescription("File written by the Export tool"),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		filename := strings.TrimPrefix(request.Params.URI, exportResourcePrefix)
+		path, err := directoryFile(e.Directory, filename)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("export %s not found", filename)
+		}
+		if info.Size() > maxExportResourceBytes {
+			return nil, fmt.Errorf("export %s is %d bytes, too large to be read as a resource", filename, info.Size())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		mimeType := "text/plain"
+		for _, format := range exportFormats {
+			if strings.HasSuffix(filename, format.Extension) {
+				mimeType = format.MIMEType
+			}
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: mimeType,
+				Text:     string(data),
+			},
+		}, nil
+	}
+	return
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// parseDocument parses an Extended JSON document and keeps the order of its keys
+func parseDocument(document string) (bson.D, error) {
+	var doc bson.D
+	if document == "" {
+		return bson.D{}, nil
+	}
+	if err := bson.UnmarshalExtJSON([]byte(document), false, &doc); err != nil {
+		return nil, fmt.Errorf("parse document failed: %w", err)
+	}
+	return doc, nil
+}
+
+// parseDocuments parses an Extended JSON array of documents and keeps the order of their keys
+func parseDocuments(documents string) ([]bson.D, error) {
+	// UnmarshalExtJSON only accepts documents, so wrap the array
+	var wrapper struct {
+		Documents []bson.D `bson:"documents"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"documents":`+documents+`}`), false, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Documents, nil
+}
+
+// parsePipeline parses an Extended JSON array of aggregation stages
+func parsePipeline(pipeline string) (mongo.Pipeline, error) {
+	if pipeline == "" {
+		return mongo.Pipeline{}, nil
+	}
+	stages, err := parseDocuments(pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("parse pipeline failed: %w", err)
+	}
+	return stages, nil
+}
+
+// lookup returns the value of a top level key of an ordered document
+func lookup(doc bson.D, key string) (interface{}, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// formatDocuments renders documents as relaxed Extended JSON, one per line
+func formatDocuments[T any](documents []T) (string, error) {
+	var result string
+	for _, doc := range documents {
+		data, err := bson.MarshalExtJSON(doc, false, false)
+		if err != nil {
+			return "", err
+		}
+		result += string(data) + "\n"
+	}
+	return result, nil
+}
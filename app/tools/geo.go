@@ -0,0 +1,428 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"slices"
+)
+
+// earthRadiusMeters is the radius MongoDB uses to convert distances to radians
+const earthRadiusMeters = 6378100
+
+// geoUnits are the distance units accepted by the geo tools, in meters
+var geoUnits = map[string]float64{
+	"meters":     1,
+	"kilometers": 1000,
+	"miles":      1609.344,
+	"feet":       0.3048,
+}
+
+type GeoTool interface {
+	// GeoNear get documents nearest to a point with their distance
+	GeoNear() (mcp.Tool, server.ToolHandlerFunc)
+	// GeoWithin get documents located inside an area
+	GeoWithin() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type geoTool struct{}
+
+func NewGeoTool() GeoTool {
+	return &geoTool{}
+}
+
+// unitMeters returns the length of a unit in meters, meters when unit is empty
+func unitMeters(unit string) (float64, error) {
+	if unit == "" {
+		return 1, nil
+	}
+	meters, ok := geoUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %s, use meters, kilometers, miles or feet", unit)
+	}
+	return meters, nil
+}
+
+// geoPosition validates a GeoJSON position, [longitude, latitude] with an optional altitude
+func geoPosition(value interface{}) ([]float64, error) {
+	var position []float64
+	switch coordinates := value.(type) {
+	case []float64:
+		position = coordinates
+	case []interface{}:
+		for _, coordinate := range coordinates {
+			switch n := coordinate.(type) {
+			case float64:
+				position = append(position, n)
+			case int:
+				position = append(position, float64(n))
+			case int64:
+				position = append(position, float64(n))
+			default:
+				return nil, fmt.Errorf("position %v must only contain numbers", value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("position %v must be an array [longitude, latitude]", value)
+	}
+	if len(position) != 2 && len(position) != 3 {
+		return nil, fmt.Errorf("position %v must be [longitude, latitude]", value)
+	}
+	if position[0] < -180 || position[0] > 180 {
+		return nil, fmt.Errorf("longitude %v must be between -180 and 180, positions are [longitude, latitude]", position[0])
+	}
+	if position[1] < -90 || position[1] > 90 {
+		return nil, fmt.Errorf("latitude %v must be between -90 and 90, positions are [longitude, latitude]", position[1])
+	}
+	return position, nil
+}
+
+// geoPolygon validates the linear rings of a GeoJSON polygon, closed with at least four positions
+func geoPolygon(value interface{}) error {
+	rings, ok := value.([]interface{})
+	if !ok || len(rings) == 0 {
+		return fmt.Errorf("polygon coordinates must be an array of linear rings")
+	}
+	for i, value := range rings {
+		ring, ok := value.([]interface{})
+		if !ok || len(ring) < 4 {
+			return fmt.Errorf("ring %d must have at least four positions", i)
+		}
+		var positions [][]float64
+		for _, value := range ring {
+			position, err := geoPosition(value)
+			if err != nil {
+				return err
+			}
+			positions = append(positions, position)
+		}
+		if !slices.Equal(positions[0], positions[len(positions)-1]) {
+			return fmt.Errorf("ring %d must be closed, its last position equal to the first", i)
+		}
+	}
+	return nil
+}
+
+// validateGeometry checks a GeoJSON geometry has one of the allowed types and valid coordinates
+func validateGeometry(geometry map[string]interface{}, allowed ...string) error {
+	geometryType, _ := geometry["type"].(string)
+	if !slices.Contains(allowed, geometryType) {
+		return fmt.Errorf("geometry type %q is not supported, use %v", geometryType, allowed)
+	}
+	coordinates, ok := geometry["coordinates"]
+	if !ok {
+		return fmt.Errorf("geometry has no coordinates")
+	}
+	switch geometryType {
+	case "Point":
+		_, err := geoPosition(coordinates)
+		return err
+	case "Polygon":
+		return geoPolygon(coordinates)
+	case "MultiPolygon":
+		polygons, ok := coordinates.([]interface{})
+		if !ok || len(polygons) == 0 {
+			return fmt.Errorf("multipolygon coordinates must be an array of polygons")
+		}
+		for _, polygon := range polygons {
+			if err := geoPolygon(polygon); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GeoNear get documents nearest to a point with their distance
+func (g geoTool) GeoNear() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"GeoNear",
+		mcp.WithDescription("Get the documents nearest to a point, sorted by distance, with the distance in the chosen unit. "+
+			"Requires a 2dsphere index, or a 2d index when spherical is false"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to query"),
+		),
+		mcp.WithObject("near",
+			mcp.Required(),
+			mcp.Description("GeoJSON point (e.g., {\"type\": \"Point\", \"coordinates\": [2.3522, 48.8566]}), coordinates are [longitude, latitude]"),
+		),
+		mcp.WithString("key",
+			mcp.Description("Geo indexed field, required when the collection has several geo indexes"),
+		),
+		mcp.WithNumber("min_distance",
+			mcp.Description("Minimum distance from the point, in the unit"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("max_distance",
+			mcp.Description("Maximum distance from the point, in the unit"),
+			mcp.Min(0),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit of the distances"),
+			mcp.Enum("meters", "kilometers", "miles", "feet"),
+			mcp.DefaultString("meters"),
+		),
+		mcp.WithBoolean("spherical",
+			mcp.Description("Compute distances on a sphere with a 2dsphere index, false for flat distances "+
+				"with a 2d index, in the units of the legacy coordinates"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("MongoDB query filter on the other fields"),
+		),
+		mcp.WithString("distance_field",
+			mcp.Description("Field receiving the distance in the returned documents"),
+			mcp.DefaultString("_distance"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Number of documents to return"),
+			mcp.DefaultNumber(20),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.GeoNearRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Limit <= 0 {
+			req.Limit = 20
+		}
+		if req.DistanceField == "" {
+			req.DistanceField = "_distance"
+		}
+		if req.Unit == "" {
+			req.Unit = "meters"
+		}
+		spherical := req.Spherical == nil || *req.Spherical
+
+		if err = validateGeometry(req.Near, "Point"); err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("invalid near: %v", err)), nil
+		}
+		meters, err := unitMeters(req.Unit)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if req.MaxDistance > 0 && req.MinDistance > req.MaxDistance {
+			return mcp.NewToolResultText("min_distance must be lower than max_distance"), nil
+		}
+
+		log.Printf("GeoNear in collection: %s, near: %v, max distance: %v %s", req.Collection, req.Near, req.MaxDistance, req.Unit)
+
+		geoNear := bson.D{{Key: "distanceField", Value: req.DistanceField}, {Key: "spherical", Value: spherical}}
+		if spherical {
+			// GeoJSON distances are in meters
+			geoNear = append(geoNear,
+				bson.E{Key: "near", Value: req.Near},
+				bson.E{Key: "distanceMultiplier", Value: 1 / meters},
+			)
+		} else {
+			// legacy coordinates are compared on a flat plane, distances stay in their units
+			meters = 1
+			geoNear = append(geoNear, bson.E{Key: "near", Value: req.Near["coordinates"]})
+		}
+		if req.Key != "" {
+			geoNear = append(geoNear, bson.E{Key: "key", Value: req.Key})
+		}
+		if req.MinDistance > 0 {
+			geoNear = append(geoNear, bson.E{Key: "minDistance", Value: req.MinDistance * meters})
+		}
+		if req.MaxDistance > 0 {
+			geoNear = append(geoNear, bson.E{Key: "maxDistance", Value: req.MaxDistance * meters})
+		}
+		if len(req.Filter) > 0 {
+			geoNear = append(geoNear, bson.E{Key: "query", Value: req.Filter})
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$geoNear", Value: geoNear}},
+			{{Key: "$limit", Value: req.Limit}},
+		}
+		cur, err := client.DB.Collection(req.Collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var documents []bson.D
+		if err = cur.All(ctx, &documents); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(documents) == 0 {
+			return mcp.NewToolResultText("No documents found"), nil
+		}
+		result, err := formatDocuments(documents)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		unit := req.Unit
+		if !spherical {
+			unit = "coordinate units"
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%d documents, %s in %s\n%s", len(documents), req.DistanceField, unit, result)), nil
+	}
+	return
+}
+
+// GeoWithin get documents located inside an area
+func (g geoTool) GeoWithin() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"GeoWithin",
+		mcp.WithDescription("Get the documents whose location is inside a polygon, a circle or a box. "+
+			"Pass exactly one of geometry, center with radius, or box. Coordinates are [longitude, latitude]"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to query"),
+		),
+		mcp.WithString("field",
+			mcp.Required(),
+			mcp.Description("Field holding the GeoJSON locations"),
+		),
+		mcp.WithObject("geometry",
+			mcp.Description("GeoJSON Polygon or MultiPolygon, rings must be closed "+
+				"(e.g., {\"type\": \"Polygon\", \"coordinates\": [[[0, 0], [3, 6], [6, 1], [0, 0]]]})"),
+		),
+		mcp.WithArray("center",
+			mcp.Description("Center of a circle as [longitude, latitude]"),
+			mcp.Items(map[string]interface{}{"type": "number"}),
+		),
+		mcp.WithNumber("radius",
+			mcp.Description("Radius of the circle, in the unit"),
+			mcp.Min(0),
+		),
+		mcp.WithArray("box",
+			mcp.Description("Box as [[west longitude, south latitude], [east longitude, north latitude]], less than 180 degrees wide. "+
+				"Its edges follow great circles, not parallels"),
+			mcp.Items(map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}}),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit of the radius"),
+			mcp.Enum("meters", "kilometers", "miles", "feet"),
+			mcp.DefaultString("meters"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("MongoDB query filter on the other fields"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Number of documents to return"),
+			mcp.DefaultNumber(100),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.GeoWithinRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Limit <= 0 {
+			req.Limit = 100
+		}
+
+		shapes := 0
+		for _, given := range []bool{req.Geometry != nil, req.Center != nil, req.Box != nil} {
+			if given {
+				shapes++
+			}
+		}
+		if shapes != 1 {
+			return mcp.NewToolResultText("Pass exactly one of geometry, center with radius, or box"), nil
+		}
+
+		var within bson.M
+		switch {
+		case req.Geometry != nil:
+			if err = validateGeometry(req.Geometry, "Polygon", "MultiPolygon"); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("invalid geometry: %v", err)), nil
+			}
+			within = bson.M{"$geometry": req.Geometry}
+		case req.Center != nil:
+			center, err := geoPosition(req.Center)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("invalid center: %v", err)), nil
+			}
+			meters, err := unitMeters(req.Unit)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			if req.Radius <= 0 {
+				return mcp.NewToolResultText("radius must be greater than 0"), nil
+			}
+			// $centerSphere takes the radius in radians
+			within = bson.M{"$centerSphere": bson.A{center[:2], req.Radius * meters / earthRadiusMeters}}
+		default:
+			if len(req.Box) != 2 {
+				return mcp.NewToolResultText("box must be [[west, south], [east, north]]"), nil
+			}
+			southWest, err := geoPosition(req.Box[0])
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("invalid box: %v", err)), nil
+			}
+			northEast, err := geoPosition(req.Box[1])
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("invalid box: %v", err)), nil
+			}
+			west, south, east, north := southWest[0], southWest[1], northEast[0], northEast[1]
+			if west >= east || south >= north {
+				return mcp.NewToolResultText("box must be [[west, south], [east, north]] with west < east and south < north"), nil
+			}
+			// a polygon wider than a hemisphere is ambiguous, MongoDB picks the smaller area
+			if east-west >= 180 {
+				return mcp.NewToolResultText("box must span less than 180 degrees of longitude, split it or pass a geometry"), nil
+			}
+			// $box only works on legacy coordinates, use a polygon for GeoJSON. Its edges are geodesics,
+			// so the south and north edges bulge towards the pole and the area only approximates the box
+			within = bson.M{"$geometry": bson.M{
+				"type": "Polygon",
+				"coordinates": bson.A{bson.A{
+					bson.A{west, south}, bson.A{east, south}, bson.A{east, north}, bson.A{west, north}, bson.A{west, south},
+				}},
+			}}
+		}
+
+		// the filter may have its own condition on the field, both must hold
+		var filter interface{} = bson.M{req.Field: bson.M{"$geoWithin": within}}
+		if len(req.Filter) > 0 {
+			filter = bson.M{"$and": bson.A{req.Filter, filter}}
+		}
+
+		log.Printf("GeoWithin in collection: %s, filter: %v", req.Collection, filter)
+
+		cur, err := client.DB.Collection(req.Collection).Find(ctx, filter, options.Find().SetLimit(req.Limit))
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var documents []bson.D
+		if err = cur.All(ctx, &documents); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(documents) == 0 {
+			return mcp.NewToolResultText("No documents found"), nil
+		}
+		result, err := formatDocuments(documents)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
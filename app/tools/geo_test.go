@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// ring is a closed square ring as decoded from JSON arguments
+func ring(west, south, east, north float64) []interface{} {
+	return []interface{}{
+		[]interface{}{west, south}, []interface{}{east, south}, []interface{}{east, north},
+		[]interface{}{west, north}, []interface{}{west, south},
+	}
+}
+
+func TestValidateGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry map[string]interface{}
+		allowed  []string
+		wantErr  string
+	}{
+		{
+			name:     "point",
+			geometry: map[string]interface{}{"type": "Point", "coordinates": []interface{}{2.3522, 48.8566}},
+			allowed:  []string{"Point"},
+		},
+		{
+			name:     "point with altitude",
+			geometry: map[string]interface{}{"type": "Point", "coordinates": []interface{}{2.3522, 48.8566, 35.0}},
+			allowed:  []string{"Point"},
+		},
+		{
+			name:     "latitude first",
+			geometry: map[string]interface{}{"type": "Point", "coordinates": []interface{}{48.8566, 122.4}},
+			allowed:  []string{"Point"},
+			wantErr:  "latitude 122.4 must be between -90 and 90",
+		},
+		{
+			name:     "longitude out of range",
+			geometry: map[string]interface{}{"type": "Point", "coordinates": []interface{}{181.0, 0.0}},
+			allowed:  []string{"Point"},
+			wantErr:  "longitude 181 must be between -180 and 180",
+		},
+		{
+			name:     "not a number",
+			geometry: map[string]interface{}{"type": "Point", "coordinates": []interface{}{"2", 48.0}},
+			allowed:  []string{"Point"},
+			wantErr:  "must only contain numbers",
+		},
+		{
+			name:     "type not allowed",
+			geometry: map[string]interface{}{"type": "Point", "coordinates": []interface{}{0.0, 0.0}},
+			allowed:  []string{"Polygon", "MultiPolygon"},
+			wantErr:  "is not supported",
+		},
+		{
+			name:     "missing coordinates",
+			geometry: map[string]interface{}{"type": "Point"},
+			allowed:  []string{"Point"},
+			wantErr:  "no coordinates",
+		},
+		{
+			name:     "polygon",
+			geometry: map[string]interface{}{"type": "Polygon", "coordinates": []interface{}{ring(0, 0, 1, 1)}},
+			allowed:  []string{"Polygon"},
+		},
+		{
+			name: "open polygon",
+			geometry: map[string]interface{}{"type": "Polygon", "coordinates": []interface{}{[]interface{}{
+				[]interface{}{0.0, 0.0}, []interface{}{1.0, 0.0}, []interface{}{1.0, 1.0}, []interface{}{0.0, 1.0},
+			}}},
+			allowed: []string{"Polygon"},
+			wantErr: "ring 0 must be closed",
+		},
+		{
+			name: "short ring",
+			geometry: map[string]interface{}{"type": "Polygon", "coordinates": []interface{}{[]interface{}{
+				[]interface{}{0.0, 0.0}, []interface{}{1.0, 0.0}, []interface{}{0.0, 0.0},
+			}}},
+			allowed: []string{"Polygon"},
+			wantErr: "at least four positions",
+		},
+		{
+			name: "multipolygon",
+			geometry: map[string]interface{}{"type": "MultiPolygon", "coordinates": []interface{}{
+				[]interface{}{ring(0, 0, 1, 1)}, []interface{}{ring(2, 2, 3, 3)},
+			}},
+			allowed: []string{"MultiPolygon"},
+		},
+		{
+			name:     "empty multipolygon",
+			geometry: map[string]interface{}{"type": "MultiPolygon", "coordinates": []interface{}{}},
+			allowed:  []string{"MultiPolygon"},
+			wantErr:  "array of polygons",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGeometry(tt.geometry, tt.allowed...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeoWithin(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	_, handler := NewGeoTool().GeoWithin()
+
+	mt.Run("box wider than a hemisphere", func(mt *mtest.T) {
+		mockDB(mt)
+		text, err := callTool(t, handler, map[string]interface{}{
+			"collection": "places", "field": "location", "box": []interface{}{[]interface{}{-100.0, 0.0}, []interface{}{90.0, 10.0}},
+		})
+		if err != nil || !strings.Contains(text, "less than 180 degrees") {
+			t.Errorf("result = %q, %v", text, err)
+		}
+	})
+
+	mt.Run("filter on the same field", func(mt *mtest.T) {
+		mockDB(mt)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.places", mtest.FirstBatch))
+		_, err := callTool(t, handler, map[string]interface{}{
+			"collection": "places",
+			"field":      "location",
+			"center":     []interface{}{2.35, 48.85},
+			"radius":     1000.0,
+			"filter":     map[string]interface{}{"location": map[string]interface{}{"$exists": true}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		conditions, ok := mt.GetStartedEvent().Command.Lookup("filter", "$and").ArrayOK()
+		if !ok {
+			t.Fatal("filter has no $and")
+		}
+		values, _ := conditions.Values()
+		if len(values) != 2 {
+			t.Fatalf("$and has %d conditions, want 2", len(values))
+		}
+		if _, err = values[0].Document().LookupErr("location", "$exists"); err != nil {
+			t.Errorf("user filter lost: %v", values[0])
+		}
+		if _, err = values[1].Document().LookupErr("location", "$geoWithin", "$centerSphere"); err != nil {
+			t.Errorf("geo condition lost: %v", values[1])
+		}
+	})
+}
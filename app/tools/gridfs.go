@@ -0,0 +1,524 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultGridFSBucket = "fs"
+	// maxGridFSBytes bounds the files exchanged inline with the client
+	maxGridFSBytes = 16 * 1024 * 1024
+)
+
+type GridFSTool interface {
+	// ListGridFSBuckets get GridFS buckets in mongodb
+	ListGridFSBuckets() (mcp.Tool, server.ToolHandlerFunc)
+	// ListGridFSFiles get files of a GridFS bucket
+	ListGridFSFiles() (mcp.Tool, server.ToolHandlerFunc)
+	// GetGridFSFile get the metadata of a GridFS file
+	GetGridFSFile() (mcp.Tool, server.ToolHandlerFunc)
+	// DownloadGridFSFile get the contents of a GridFS file
+	DownloadGridFSFile() (mcp.Tool, server.ToolHandlerFunc)
+	// UploadGridFSFile store a file in a GridFS bucket
+	UploadGridFSFile() (mcp.Tool, server.ToolHandlerFunc)
+	// DeleteGridFSFile delete a GridFS file
+	DeleteGridFSFile() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type gridFSTool struct{}
+
+func NewGridFSTool() GridFSTool {
+	return &gridFSTool{}
+}
+
+// gridFSFile is a document of the files collection of a bucket
+type gridFSFile struct {
+	ID          interface{} `bson:"_id"`
+	Filename    string      `bson:"filename"`
+	Length      int64       `bson:"length"`
+	ChunkSize   int32       `bson:"chunkSize"`
+	UploadDate  time.Time   `bson:"uploadDate"`
+	ContentType string      `bson:"contentType"`
+	Metadata    bson.D      `bson:"metadata"`
+}
+
+// mimeType returns the content type recorded in the metadata, or in the deprecated top level field
+func (f gridFSFile) mimeType() string {
+	if value, ok := lookup(f.Metadata, "contentType"); ok {
+		if contentType, ok := value.(string); ok {
+			return contentType
+		}
+	}
+	return f.ContentType
+}
+
+// id renders the file id the way file_id takes it, ObjectIds as hex strings
+func (f gridFSFile) id() string {
+	switch id := f.ID.(type) {
+	case primitive.ObjectID:
+		return id.Hex()
+	case string:
+		return id
+	default:
+		value, err := bson.MarshalExtJSON(bson.D{{Key: "_id", Value: id}}, false, false)
+		if err != nil {
+			return fmt.Sprint(id)
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(string(value), `{"_id":`), "}")
+	}
+}
+
+func (f gridFSFile) String() string {
+	result := fmt.Sprintf("_id: %s, filename: %s, length: %d, uploadDate: %s",
+		f.id(), f.Filename, f.Length, f.UploadDate.Format(time.RFC3339))
+	if contentType := f.mimeType(); contentType != "" {
+		result += ", contentType: " + contentType
+	}
+	if len(f.Metadata) > 0 {
+		metadata, _ := bson.MarshalExtJSON(f.Metadata, false, false)
+		result += ", metadata: " + string(metadata)
+	}
+	return result
+}
+
+// gridFSBucket opens a bucket, fs when name is empty, with the deadline of ctx
+func gridFSBucket(ctx context.Context, name string) (*gridfs.Bucket, error) {
+	if name == "" {
+		name = defaultGridFSBucket
+	}
+	bucket, err := gridfs.NewBucket(client.DB, options.GridFSBucket().SetName(name))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = bucket.SetReadDeadline(deadline)
+		_ = bucket.SetWriteDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+// errGridFSFileNotFound is returned by the lookups of a file which doesn't exist
+var errGridFSFileNotFound = errors.New("file not found")
+
+// gridFSFileIDs returns the ids a file id argument may stand for: the Extended JSON value,
+// the ObjectId of a hex string, and the string itself
+func gridFSFileIDs(id string) bson.A {
+	ids := bson.A{}
+	var wrapper struct {
+		ID interface{} `bson:"id"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"id":`+id+`}`), false, &wrapper); err == nil {
+		ids = append(ids, wrapper.ID)
+	}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		ids = append(ids, oid)
+	}
+	return append(ids, id)
+}
+
+// findGridFSFile returns the file with the id, or the latest revision of the file with the name
+func findGridFSFile(ctx context.Context, bucket *gridfs.Bucket, id string, filename string) (gridFSFile, error) {
+	var file gridFSFile
+	var filter bson.M
+	switch {
+	case id != "":
+		filter = bson.M{"_id": bson.M{"$in": gridFSFileIDs(id)}}
+	case filename != "":
+		filter = bson.M{"filename": filename}
+	default:
+		return file, fmt.Errorf("file_id or filename is required")
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "uploadDate", Value: -1}})
+	err := bucket.GetFilesCollection().FindOne(ctx, filter, opts).Decode(&file)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return file, errGridFSFileNotFound
+	}
+	return file, err
+}
+
+// isText tells whether contents can be returned as text
+func isText(contentType string, data []byte) bool {
+	switch {
+	case strings.HasPrefix(contentType, "text/"),
+		strings.HasSuffix(contentType, "json"),
+		strings.HasSuffix(contentType, "xml"),
+		contentType == "application/javascript":
+		return true
+	case contentType == "":
+		return utf8.Valid(data) && !bytes.ContainsRune(data, 0)
+	}
+	return false
+}
+
+// ListGridFSBuckets get GridFS buckets in mongodb
+func (g gridFSTool) ListGridFSBuckets() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ListGridFSBuckets",
+		mcp.WithDescription("List the GridFS buckets in mongodb with their number of files"),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		infos, err := listCollectionInfos(ctx, bson.M{"name": bson.M{"$regex": `\.(files|chunks)$`}})
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		names := map[string]bool{}
+		for _, info := range infos {
+			names[info.Name] = true
+		}
+
+		var result string
+		for _, info := range infos {
+			bucket, ok := strings.CutSuffix(info.Name, ".files")
+			if !ok || !names[bucket+".chunks"] {
+				continue
+			}
+			count, err := client.DB.Collection(info.Name).EstimatedDocumentCount(ctx)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			result += fmt.Sprintf("bucket: %s, files: %d\n", bucket, count)
+		}
+		if result == "" {
+			return mcp.NewToolResultText("No GridFS buckets found"), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// ListGridFSFiles get files of a GridFS bucket
+func (g gridFSTool) ListGridFSFiles() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ListGridFSFiles",
+		mcp.WithDescription("List the files of a GridFS bucket, most recent first"),
+		mcp.WithString("bucket",
+			mcp.Description("Bucket name"),
+			mcp.DefaultString(defaultGridFSBucket),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("MongoDB query filter on the files, metadata fields are under metadata "+
+				"(e.g., {\"metadata.owner\": \"alice\", \"filename\": {\"$regex\": \"\\\\.pdf$\"}})"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of files to return"),
+			mcp.DefaultNumber(100),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ListGridFSFilesRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Limit <= 0 {
+			req.Limit = 100
+		}
+		if req.Filter == nil {
+			req.Filter = map[string]interface{}{}
+		}
+
+		bucket, err := gridFSBucket(ctx, req.Bucket)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		opts := options.GridFSFind().SetSort(bson.D{{Key: "uploadDate", Value: -1}}).SetLimit(int32(req.Limit))
+		cur, err := bucket.FindContext(ctx, req.Filter, opts)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var files []gridFSFile
+		if err = cur.All(ctx, &files); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(files) == 0 {
+			return mcp.NewToolResultText("No files found"), nil
+		}
+		var result string
+		for _, file := range files {
+			result += file.String() + "\n"
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// GetGridFSFile get the metadata of a GridFS file
+func (g gridFSTool) GetGridFSFile() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"GetGridFSFile",
+		mcp.WithDescription("Get the metadata of a GridFS file by id, or of the latest revision of a filename"),
+		mcp.WithString("bucket",
+			mcp.Description("Bucket name"),
+			mcp.DefaultString(defaultGridFSBucket),
+		),
+		mcp.WithString("file_id",
+			mcp.Description("File id, ObjectIds as hex strings, other ids as Extended JSON (e.g., 42 or {\"$uuid\": \"...\"})"),
+		),
+		mcp.WithString("filename",
+			mcp.Description("Filename, used when file_id is not given"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.GridFSFileRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		bucket, err := gridFSBucket(ctx, req.Bucket)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		file, err := findGridFSFile(ctx, bucket, req.FileID, req.Filename)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s, chunkSize: %d", file, file.ChunkSize)), nil
+	}
+	return
+}
+
+// DownloadGridFSFile get the contents of a GridFS file
+func (g gridFSTool) DownloadGridFSFile() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"DownloadGridFSFile",
+		mcp.WithDescription("Get the contents of a GridFS file by id, or of the latest revision of a filename. "+
+			"Text files are returned inline, binary files as an embedded resource or base64"),
+		mcp.WithString("bucket",
+			mcp.Description("Bucket name"),
+			mcp.DefaultString(defaultGridFSBucket),
+		),
+		mcp.WithString("file_id",
+			mcp.Description("File id, ObjectIds as hex strings, other ids as Extended JSON (e.g., 42 or {\"$uuid\": \"...\"})"),
+		),
+		mcp.WithString("filename",
+			mcp.Description("Filename, used when file_id is not given"),
+		),
+		mcp.WithString("as",
+			mcp.Description("auto returns text inline and binary files as an embedded resource"),
+			mcp.Enum("auto", "text", "base64", "resource"),
+			mcp.DefaultString("auto"),
+		),
+		mcp.WithNumber("max_bytes",
+			mcp.Description("Refuse files larger than this"),
+			mcp.DefaultNumber(1024*1024),
+			mcp.Min(1),
+			mcp.Max(maxGridFSBytes),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.DownloadGridFSFileRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.MaxBytes <= 0 {
+			req.MaxBytes = 1024 * 1024
+		}
+		if req.MaxBytes > maxGridFSBytes {
+			req.MaxBytes = maxGridFSBytes
+		}
+		if req.As == "" {
+			req.As = "auto"
+		}
+
+		bucket, err := gridFSBucket(ctx, req.Bucket)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		file, err := findGridFSFile(ctx, bucket, req.FileID, req.Filename)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if file.Length > req.MaxBytes {
+			return mcp.NewToolResultText(fmt.Sprintf("File %s is %d bytes, larger than max_bytes %d", file.Filename, file.Length, req.MaxBytes)), nil
+		}
+
+		log.Printf("Download GridFS file %s (%s), %d bytes", file.id(), file.Filename, file.Length)
+
+		var buffer bytes.Buffer
+		if _, err = bucket.DownloadToStream(file.ID, &buffer); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		data := buffer.Bytes()
+		contentType := file.mimeType()
+		if req.As == "auto" {
+			req.As = "resource"
+			if isText(contentType, data) {
+				req.As = "text"
+			}
+		}
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+
+		summary := fmt.Sprintf("%s, %d bytes, %s", file.Filename, len(data), contentType)
+		switch req.As {
+		case "text":
+			if !utf8.Valid(data) {
+				return mcp.NewToolResultText(fmt.Sprintf("File %s is not valid UTF-8 text, download it as base64 or resource", file.Filename)), nil
+			}
+			return mcp.NewToolResultText(string(data)), nil
+		case "base64":
+			return mcp.NewToolResultText(summary + ", base64:\n" + base64.StdEncoding.EncodeToString(data)), nil
+		default:
+			return mcp.NewToolResultResource(summary, mcp.BlobResourceContents{
+				URI:      fmt.Sprintf("gridfs://%s/%s", strings.TrimSuffix(bucket.GetFilesCollection().Name(), ".files"), url.PathEscape(file.id())),
+				MIMEType: contentType,
+				Blob:     base64.StdEncoding.EncodeToString(data),
+			}), nil
+		}
+	}
+	return
+}
+
+// UploadGridFSFile store a file in a GridFS bucket
+func (g gridFSTool) UploadGridFSFile() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"UploadGridFSFile",
+		mcp.WithDescription("Store a base64 encoded file in a GridFS bucket, uploading an existing filename adds a new revision"),
+		mcp.WithString("bucket",
+			mcp.Description("Bucket name"),
+			mcp.DefaultString(defaultGridFSBucket),
+		),
+		mcp.WithString("filename",
+			mcp.Required(),
+			mcp.Description("Filename"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("File contents encoded in base64, up to 16MB once decoded"),
+		),
+		mcp.WithString("content_type",
+			mcp.Description("MIME type stored in metadata.contentType, detected from the contents when not given"),
+		),
+		mcp.WithObject("metadata",
+			mcp.Description("Metadata stored with the file"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.UploadGridFSFileRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		if base64.StdEncoding.DecodedLen(len(req.Content)) > maxGridFSBytes+2 {
+			return mcp.NewToolResultText("content is larger than 16MB"), nil
+		}
+		data, err := base64.StdEncoding.DecodeString(req.Content)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("content is not valid base64: %v", err)), nil
+		}
+		if req.ContentType == "" {
+			req.ContentType = http.DetectContentType(data)
+		}
+		metadata := bson.M{}
+		for key, value := range req.Metadata {
+			metadata[key] = value
+		}
+		metadata["contentType"] = req.ContentType
+
+		bucket, err := gridFSBucket(ctx, req.Bucket)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		log.Printf("Upload GridFS file %s, %d bytes", req.Filename, len(data))
+		id, err := bucket.UploadFromStream(req.Filename, bytes.NewReader(data), options.GridFSUpload().SetMetadata(metadata))
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("File %s uploaded, file_id: %s, %d bytes, %s", req.Filename, id.Hex(), len(data), req.ContentType)), nil
+	}
+	return
+}
+
+// DeleteGridFSFile delete a GridFS file
+func (g gridFSTool) DeleteGridFSFile() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"DeleteGridFSFile",
+		mcp.WithDescription("Delete a GridFS file and its chunks"),
+		mcp.WithString("bucket",
+			mcp.Description("Bucket name"),
+			mcp.DefaultString(defaultGridFSBucket),
+		),
+		mcp.WithString("file_id",
+			mcp.Required(),
+			mcp.Description("File id, ObjectIds as hex strings, other ids as Extended JSON (e.g., 42 or {\"$uuid\": \"...\"})"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.GridFSFileRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.FileID == "" {
+			return mcp.NewToolResultText("file_id is required"), nil
+		}
+
+		bucket, err := gridFSBucket(ctx, req.Bucket)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		log.Printf("Delete GridFS file %s", req.FileID)
+		file, err := findGridFSFile(ctx, bucket, req.FileID, "")
+		if errors.Is(err, errGridFSFileNotFound) {
+			return mcp.NewToolResultText(fmt.Sprintf("file %s not found", req.FileID)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		err = bucket.DeleteContext(ctx, file.ID)
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return mcp.NewToolResultText(fmt.Sprintf("file %s not found", req.FileID)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("File %s deleted", req.FileID)), nil
+	}
+	return
+}
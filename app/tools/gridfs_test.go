@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGridFSFileIDs(t *testing.T) {
+	oid, _ := primitive.ObjectIDFromHex("65a1b2c3d4e5f60718293a4b")
+	tests := []struct {
+		id   string
+		want bson.A
+	}{
+		{"65a1b2c3d4e5f60718293a4b", bson.A{oid, "65a1b2c3d4e5f60718293a4b"}},
+		{`{"$oid": "65a1b2c3d4e5f60718293a4b"}`, bson.A{oid, `{"$oid": "65a1b2c3d4e5f60718293a4b"}`}},
+		{"42", bson.A{int32(42), "42"}},
+		{"report.pdf", bson.A{"report.pdf"}},
+		{`"report.pdf"`, bson.A{"report.pdf", `"report.pdf"`}},
+	}
+	for _, tt := range tests {
+		if got := gridFSFileIDs(tt.id); !equalValues(got, tt.want) {
+			t.Errorf("gridFSFileIDs(%s) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -3,39 +3,163 @@ package tools
 import (
 	"context"
 	"fmt"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"mcp/app/client"
+	"mcp/app/configs"
+	"mcp/app/model"
+	"sort"
+	"strings"
+	"time"
 )
 
+const (
+	defaultCountersCollection = "counters"
+	defaultIdWidth            = 4
+	defaultIdSeparator        = "-"
+)
+
+// defaultEntities is used when no entity is configured
+var defaultEntities = map[string]configs.EntityIdConfig{
+	"task":       {Prefix: "TSK"},
+	"lesson":     {Prefix: "LSN"},
+	"course":     {Prefix: "CRS"},
+	"experiment": {Prefix: "EXP"},
+}
+
 type IdGenerateTool interface {
 	Generate() (mcp.Tool, server.ToolHandlerFunc)
+	// ListCounters list the counters of sequential ids
+	ListCounters() (mcp.Tool, server.ToolHandlerFunc)
+	// PeekId get the next id without consuming it
+	PeekId() (mcp.Tool, server.ToolHandlerFunc)
+	// SetCounter reset or set a counter
+	SetCounter() (mcp.Tool, server.ToolHandlerFunc)
+	// ReconcileCounter raise a counter above the highest id found in a collection
+	ReconcileCounter() (mcp.Tool, server.ToolHandlerFunc)
+	// ListScopes list the scopes of each id prefix
+	ListScopes() (mcp.Tool, server.ToolHandlerFunc)
 }
 
 type idGenerateTool struct {
-	EntityPrefixMap map[string]interface{}
+	Entities           map[string]configs.EntityIdConfig
+	CountersCollection string
+	NodeId             int64
 }
 
-func NewIdGenerateTool() IdGenerateTool {
-	return &idGenerateTool{
-		EntityPrefixMap: map[string]interface{}{
-			"task":       "TSK",
-			"lesson":     "LSN",
-			"course":     "CRS",
-			"experiment": "EXP",
-		},
+func NewIdGenerateTool(config configs.IdGeneratorConfig) IdGenerateTool {
+	entities := config.Entities
+	if len(entities) == 0 {
+		entities = defaultEntities
+	}
+	tool := &idGenerateTool{
+		Entities:           map[string]configs.EntityIdConfig{},
+		CountersCollection: config.CountersCollection,
+		NodeId:             config.NodeId,
+	}
+	if tool.CountersCollection == "" {
+		tool.CountersCollection = defaultCountersCollection
 	}
+	for entityType, entity := range entities {
+		tool.Entities[entityType] = withEntityDefaults(entity)
+	}
+	return tool
 }
 
-func (i idGenerateTool) Generate() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+// withEntityDefaults fills the unset format settings of an entity
+func withEntityDefaults(entity configs.EntityIdConfig) configs.EntityIdConfig {
+	if entity.Width <= 0 {
+		entity.Width = defaultIdWidth
+	}
+	if entity.Separator == "" {
+		entity.Separator = defaultIdSeparator
+	}
+	if entity.Start <= 0 {
+		entity.Start = 1
+	}
+	if entity.Strategy == "" {
+		entity.Strategy = strategySequence
+	}
+	if entity.Template == "" {
+		entity.Template = "{prefix}" + entity.Separator
+		if entity.DateFormat != "" {
+			entity.Template += "{date}" + entity.Separator
+		}
+		entity.Template += "{seq}"
+	}
+	return entity
+}
+
+// formatId renders an id with the entity template and the date and scope of its counter.
+// The template supports {prefix}, {scope}, {date} and {seq} placeholders, {seq} holds the generated value of the strategy.
+func formatId(entity configs.EntityIdConfig, value string, key counterKey) string {
+	return strings.NewReplacer(
+		"{prefix}", entity.Prefix,
+		"{scope}", key.Scope,
+		"{date}", key.Period,
+		"{seq}", value,
+	).Replace(entity.Template)
+}
+
+// formatSequence pads the sequence number, shifted by the start value of the entity
+func formatSequence(entity configs.EntityIdConfig, sequence int64) string {
+	return fmt.Sprintf("%0*d", entity.Width, sequence+entity.Start-1)
+}
+
+// counterKey identifies a sequence in the counters collection
+type counterKey struct {
+	Prefix string
+	// Period is the formatted date when the entity has a date component, sequences restart for each period
+	Period string
+	// Scope is a tenant or parent id, each scope has its own sequence
+	Scope string
+}
+
+func newCounterKey(entity configs.EntityIdConfig, scope string, now time.Time) counterKey {
+	key := counterKey{Prefix: entity.Prefix, Scope: scope}
+	if entity.DateFormat != "" {
+		key.Period = now.Format(entity.DateFormat)
+	}
+	return key
+}
+
+// counterKeyOf returns the key of the entity counter for period, or for the current period when empty
+func counterKeyOf(entity configs.EntityIdConfig, scope string, period string) counterKey {
+	key := newCounterKey(entity, scope, time.Now())
+	if period != "" && entity.DateFormat != "" {
+		key.Period = period
+	}
+	return key
+}
+
+// filter matches the counter document, unset fields match counters created without them
+func (k counterKey) filter() bson.M {
+	filter := bson.M{"id_type": k.Prefix, "period": nil, "scope": nil}
+	if k.Period != "" {
+		filter["period"] = k.Period
+	}
+	if k.Scope != "" {
+		filter["scope"] = k.Scope
+	}
+	return filter
+}
 
-	var prefixNameSet []string
-	for key := range i.EntityPrefixMap {
-		prefixNameSet = append(prefixNameSet, key)
+func (i idGenerateTool) entityTypes() []string {
+	var entityTypes []string
+	for key := range i.Entities {
+		entityTypes = append(entityTypes, key)
 	}
+	sort.Strings(entityTypes)
+	return entityTypes
+}
+
+func (i idGenerateTool) Generate() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	prefixNameSet := i.entityTypes()
 	tool = mcp.NewTool(
 		"entity_id_generator",
 		mcp.WithDescription("generate id for different type entity"),
@@ -43,35 +167,72 @@ func (i idGenerateTool) Generate() (tool mcp.Tool, handler server.ToolHandlerFun
 			mcp.Required(),
 			mcp.Description(fmt.Sprintf("type of entity to generate id, it could be one of %s", prefixNameSet)),
 		),
+		mcp.WithNumber("count",
+			mcp.Description("number of ids to generate, sequential ids are reserved as one contiguous block"),
+			mcp.DefaultNumber(1),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+		mcp.WithString("scope",
+			mcp.Description("tenant or parent id (e.g., a course id), sequential ids are unique within the scope"),
+		),
 	)
 	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		entityType := request.Params.Arguments["entity_type"].(string)
-		log.Printf("Generate id for entity: %s", entityType)
-		var entityPrefix string
-		if value, ok := i.EntityPrefixMap[entityType]; ok {
-			entityPrefix = value.(string)
-			//return mcp.NewToolResultText(prefix.(string) + "-" + mcp.GenerateId()), nil
+		var req model.GenerateIdRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Count <= 0 {
+			req.Count = 1
+		}
+		log.Printf("Generate %d id for entity: %s", req.Count, req.EntityType)
+		entity, ok := i.Entities[req.EntityType]
+		if !ok {
+			return mcp.NewToolResultText(fmt.Sprintf("entity type %s not found, please use one of %s", req.EntityType, prefixNameSet)), nil
+		}
+
+		if req.Scope != "" && entity.Strategy != strategySequence && !strings.Contains(entity.Template, "{scope}") {
+			return mcp.NewToolResultText(fmt.Sprintf("entity type %s uses the %s strategy and its template has no {scope} placeholder, "+
+				"scope would be ignored", req.EntityType, entity.Strategy)), nil
+		}
+
+		key := newCounterKey(entity, req.Scope, time.Now())
+		var values []string
+		if entity.Strategy == strategySequence {
+			last, err := i.getNextSequence(ctx, key, req.Count)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("failed to get next sequence: %v", err)), nil
+			}
+			for sequence := last - int64(req.Count) + 1; sequence <= last; sequence++ {
+				values = append(values, formatSequence(entity, sequence))
+			}
 		} else {
-			return mcp.NewToolResultText(fmt.Sprintf("entity type %s not found, please use one of %s", entityType, prefixNameSet)), nil
+			values, err = newIds(entity.Strategy, req.Count, i.NodeId)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
 		}
-		counterID, err := i.getNextSequence(ctx, entityPrefix)
-		if err != nil {
-			return mcp.NewToolResultText(fmt.Sprintf("failed to get next sequence: %v", err)), nil
+
+		ids := make([]string, 0, len(values))
+		for _, value := range values {
+			ids = append(ids, formatId(entity, value, key))
 		}
-		return mcp.NewToolResultText(fmt.Sprintf("%s-%04d", entityPrefix, counterID)), nil
+		return mcp.NewToolResultText(strings.Join(ids, "\n")), nil
 	}
 	return
 }
 
-func (i idGenerateTool) getNextSequence(ctx context.Context, counterIDType string) (int, error) {
-	filter := bson.M{"id_type": counterIDType}
-	update := bson.M{"$inc": bson.M{"sequence": 1}}
+// getNextSequence reserves count sequence numbers with a single $inc and returns the last one
+func (i idGenerateTool) getNextSequence(ctx context.Context, key counterKey, count int) (int64, error) {
+	filter := key.filter()
+	update := bson.M{"$inc": bson.M{"sequence": count}}
 	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
 
 	var result struct {
-		Sequence int `bson:"sequence"`
+		Sequence int64 `bson:"sequence"`
 	}
-	err := client.DB.Collection("counters").FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	err := client.DB.Collection(i.CountersCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
 	if err != nil {
 		return 0, err
 	}
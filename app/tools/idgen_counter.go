@@ -0,0 +1,418 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mcp/app/client"
+	"mcp/app/configs"
+	"mcp/app/model"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// counter is a document of the counters collection
+type counter struct {
+	IdType   string `bson:"id_type"`
+	Period   string `bson:"period"`
+	Scope    string `bson:"scope"`
+	Sequence int64  `bson:"sequence"`
+}
+
+// sequenceEntity returns the entity of a type which uses the sequence strategy
+func (i idGenerateTool) sequenceEntity(entityType string) (configs.EntityIdConfig, error) {
+	entity, ok := i.Entities[entityType]
+	if !ok {
+		return entity, fmt.Errorf("entity type %s not found, please use one of %s", entityType, i.entityTypes())
+	}
+	if entity.Strategy != strategySequence {
+		return entity, fmt.Errorf("entity type %s uses the %s strategy, it has no counter", entityType, entity.Strategy)
+	}
+	return entity, nil
+}
+
+// getSequence returns the current value of a counter, 0 when it doesn't exist yet
+func (i idGenerateTool) getSequence(ctx context.Context, key counterKey) (int64, error) {
+	var result counter
+	err := client.DB.Collection(i.CountersCollection).FindOne(ctx, key.filter()).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return result.Sequence, err
+}
+
+// idPattern builds a regular expression matching the ids of a counter, the sequence number is the only capture group.
+// The ids of a scope can only be told apart when the template embeds the scope.
+func idPattern(entity configs.EntityIdConfig, key counterKey) (string, error) {
+	if key.Scope != "" && !strings.Contains(entity.Template, "{scope}") {
+		return "", fmt.Errorf("the template %s of prefix %s has no {scope} placeholder, the ids of scope %s can't be told apart "+
+			"from those of other scopes", entity.Template, entity.Prefix, key.Scope)
+	}
+	date := ".+?"
+	if key.Period != "" {
+		date = regexp.QuoteMeta(key.Period)
+	}
+	scope := ".+?"
+	if key.Scope != "" {
+		scope = regexp.QuoteMeta(key.Scope)
+	}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	placeholders := regexp.MustCompile(`\{(prefix|scope|date|seq)\}`)
+	last := 0
+	for _, loc := range placeholders.FindAllStringSubmatchIndex(entity.Template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(entity.Template[last:loc[0]]))
+		switch entity.Template[loc[2]:loc[3]] {
+		case "prefix":
+			pattern.WriteString(regexp.QuoteMeta(entity.Prefix))
+		case "scope":
+			pattern.WriteString("(?:" + scope + ")")
+		case "date":
+			pattern.WriteString("(?:" + date + ")")
+		case "seq":
+			pattern.WriteString(`(\d+)`)
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(entity.Template[last:]))
+	pattern.WriteString("$")
+	return pattern.String(), nil
+}
+
+// ListCounters list the counters of sequential ids
+func (i idGenerateTool) ListCounters() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	tool = mcp.NewTool(
+		"ListIdCounters",
+		mcp.WithDescription("list the counters used by entity_id_generator with the last issued and the next id"),
+		mcp.WithString("entity_type",
+			mcp.Description(fmt.Sprintf("only list counters of this entity type, one of %s", i.entityTypes())),
+		),
+	)
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CounterRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		// map prefixes back to the configured entities
+		entities := map[string]string{}
+		for _, entityType := range i.entityTypes() {
+			entities[i.Entities[entityType].Prefix] = entityType
+		}
+		filter := bson.M{}
+		if req.EntityType != "" {
+			entity, err := i.sequenceEntity(req.EntityType)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			filter["id_type"] = entity.Prefix
+		}
+
+		opts := options.Find().SetSort(bson.D{{Key: "id_type", Value: 1}, {Key: "scope", Value: 1}, {Key: "period", Value: 1}})
+		cur, err := client.DB.Collection(i.CountersCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var counters []counter
+		if err = cur.All(ctx, &counters); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(counters) == 0 {
+			return mcp.NewToolResultText("No counters found"), nil
+		}
+
+		var result string
+		for _, c := range counters {
+			key := counterKey{Prefix: c.IdType, Period: c.Period, Scope: c.Scope}
+			result += fmt.Sprintf("prefix: %s", c.IdType)
+			if c.Scope != "" {
+				result += fmt.Sprintf(", scope: %s", c.Scope)
+			}
+			if c.Period != "" {
+				result += fmt.Sprintf(", period: %s", c.Period)
+			}
+			result += fmt.Sprintf(", sequence: %d", c.Sequence)
+			if entityType, ok := entities[c.IdType]; ok {
+				entity := i.Entities[entityType]
+				result += fmt.Sprintf(", entity: %s", entityType)
+				if c.Sequence > 0 {
+					result += fmt.Sprintf(", last: %s", formatId(entity, formatSequence(entity, c.Sequence), key))
+				}
+				result += fmt.Sprintf(", next: %s", formatId(entity, formatSequence(entity, c.Sequence+1), key))
+			}
+			result += "\n"
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// PeekId get the next id without consuming it
+func (i idGenerateTool) PeekId() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	tool = mcp.NewTool(
+		"PeekId",
+		mcp.WithDescription("get the next id entity_id_generator will generate without consuming it"),
+		mcp.WithString("entity_type",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("type of entity, it could be one of %s", i.entityTypes())),
+		),
+		mcp.WithString("scope",
+			mcp.Description("tenant or parent id of the counter, empty for the global counter"),
+		),
+		mcp.WithString("period",
+			mcp.Description("date component of the counter for entities with a date format, the current one if empty"),
+		),
+	)
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CounterRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		entity, err := i.sequenceEntity(req.EntityType)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		key := counterKeyOf(entity, req.Scope, req.Period)
+		sequence, err := i.getSequence(ctx, key)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(formatId(entity, formatSequence(entity, sequence+1), key)), nil
+	}
+	return
+}
+
+// SetCounter reset or set a counter
+func (i idGenerateTool) SetCounter() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	tool = mcp.NewTool(
+		"SetIdCounter",
+		mcp.WithDescription("reset or set the counter of an entity type. This is destructive: "+
+			"moving a counter back generates ids which may already exist. Requires confirm to be true"),
+		mcp.WithString("entity_type",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("type of entity, it could be one of %s", i.entityTypes())),
+		),
+		mcp.WithString("scope",
+			mcp.Description("tenant or parent id of the counter, empty for the global counter"),
+		),
+		mcp.WithString("period",
+			mcp.Description("date component of the counter for entities with a date format, the current one if empty"),
+		),
+		mcp.WithNumber("next_value",
+			mcp.Description("number of the next id to generate, e.g. 42 makes the next id TSK-0042"),
+			mcp.Min(1),
+		),
+		mcp.WithBoolean("reset",
+			mcp.Description("restart the counter at the configured start value, next_value is ignored"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Required(),
+			mcp.Description("must be true to apply the change"),
+		),
+	)
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.SetCounterRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		entity, err := i.sequenceEntity(req.EntityType)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if !req.Confirm {
+			return mcp.NewToolResultText("SetIdCounter is destructive, call it again with confirm set to true"), nil
+		}
+		if !req.Reset && req.NextValue < entity.Start {
+			return mcp.NewToolResultText(fmt.Sprintf("next_value must be at least the start value %d, or set reset", entity.Start)), nil
+		}
+
+		// the stored sequence is the last issued one, shifted by the start value
+		sequence := int64(0)
+		if !req.Reset {
+			sequence = req.NextValue - entity.Start
+		}
+		key := counterKeyOf(entity, req.Scope, req.Period)
+		update := bson.M{"$set": bson.M{"sequence": sequence}}
+		_, err = client.DB.Collection(i.CountersCollection).UpdateOne(ctx, key.filter(), update, options.Update().SetUpsert(true))
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(
+			fmt.Sprintf("Counter %s set, next id: %s", entity.Prefix, formatId(entity, formatSequence(entity, sequence+1), key)),
+		), nil
+	}
+	return
+}
+
+// ReconcileCounter raise a counter above the highest id found in a collection
+func (i idGenerateTool) ReconcileCounter() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	tool = mcp.NewTool(
+		"ReconcileIdCounter",
+		mcp.WithDescription("find the highest id of an entity type stored in a collection field and raise the counter "+
+			"above it, so generated ids don't collide with imported ones. The counter is never lowered"),
+		mcp.WithString("entity_type",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("type of entity, it could be one of %s", i.entityTypes())),
+		),
+		mcp.WithString("scope",
+			mcp.Description("tenant or parent id of the counter, empty for the global counter"),
+		),
+		mcp.WithString("period",
+			mcp.Description("date component of the counter for entities with a date format, the current one if empty"),
+		),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection holding the entities"),
+		),
+		mcp.WithString("field",
+			mcp.Required(),
+			mcp.Description("Field holding the generated ids"),
+		),
+	)
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ReconcileCounterRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		entity, err := i.sequenceEntity(req.EntityType)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		key := counterKeyOf(entity, req.Scope, req.Period)
+		pattern, err := idPattern(entity, key)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{req.Field: bson.M{"$regex": pattern}}}},
+			{{Key: "$project", Value: bson.M{"match": bson.M{"$regexFind": bson.M{"input": "$" + req.Field, "regex": pattern}}}}},
+			{{Key: "$group", Value: bson.M{
+				"_id":   nil,
+				"max":   bson.M{"$max": bson.M{"$toLong": bson.M{"$arrayElemAt": bson.A{"$match.captures", 0}}}},
+				"count": bson.M{"$sum": 1},
+			}}},
+		}
+		cur, err := client.DB.Collection(req.Collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var results []struct {
+			Max   int64 `bson:"max"`
+			Count int64 `bson:"count"`
+		}
+		if err = cur.All(ctx, &results); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(results) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No %s ids found in %s.%s, counter unchanged", entity.Prefix, req.Collection, req.Field)), nil
+		}
+
+		previous, err := i.getSequence(ctx, key)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		// $max only raises the counter, concurrent generations are never moved back
+		sequence := results[0].Max - entity.Start + 1
+		update := bson.M{"$max": bson.M{"sequence": sequence}}
+		_, err = client.DB.Collection(i.CountersCollection).UpdateOne(ctx, key.filter(), update, options.Update().SetUpsert(true))
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		current, err := i.getSequence(ctx, key)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		highest := formatId(entity, formatSequence(entity, sequence), key)
+		next := formatId(entity, formatSequence(entity, current+1), key)
+		if current == previous {
+			return mcp.NewToolResultText(
+				fmt.Sprintf("Found %d ids, highest: %s, counter already ahead, next id: %s", results[0].Count, highest, next),
+			), nil
+		}
+		return mcp.NewToolResultText(
+			fmt.Sprintf("Found %d ids, highest: %s, counter raised from %d to %d, next id: %s",
+				results[0].Count, highest, previous, current, next),
+		), nil
+	}
+	return
+}
+
+// ListScopes list the scopes of each id prefix
+func (i idGenerateTool) ListScopes() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	tool = mcp.NewTool(
+		"ListIdScopes",
+		mcp.WithDescription("list the scopes which have their own id sequence, grouped by prefix"),
+		mcp.WithString("entity_type",
+			mcp.Description(fmt.Sprintf("only list scopes of this entity type, one of %s", i.entityTypes())),
+		),
+	)
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CounterRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		match := bson.M{"scope": bson.M{"$nin": bson.A{nil, ""}}}
+		if req.EntityType != "" {
+			entity, err := i.sequenceEntity(req.EntityType)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			match["id_type"] = entity.Prefix
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$group", Value: bson.M{"_id": "$id_type", "scopes": bson.M{"$addToSet": "$scope"}}}},
+			{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		}
+		cur, err := client.DB.Collection(i.CountersCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var results []struct {
+			Prefix string   `bson:"_id"`
+			Scopes []string `bson:"scopes"`
+		}
+		if err = cur.All(ctx, &results); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(results) == 0 {
+			return mcp.NewToolResultText("No scoped counters found"), nil
+		}
+
+		var result string
+		for _, r := range results {
+			sort.Strings(r.Scopes)
+			result += fmt.Sprintf("%s: %s\n", r.Prefix, strings.Join(r.Scopes, ", "))
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Strategies of entity_id_generator, only the sequence strategy uses the counters collection
+const (
+	strategySequence  = "sequence"
+	strategyObjectId  = "objectid"
+	strategyUUIDv4    = "uuid4"
+	strategyUUIDv7    = "uuid7"
+	strategyULID      = "ulid"
+	strategySnowflake = "snowflake"
+)
+
+var idStrategies = []string{strategySequence, strategyObjectId, strategyUUIDv4, strategyUUIDv7, strategyULID, strategySnowflake}
+
+// newIds generates count ids with a strategy which doesn't need the counters collection
+func newIds(strategy string, count int, nodeId int64) ([]string, error) {
+	ids := make([]string, 0, count)
+	for len(ids) < count {
+		var id string
+		switch strategy {
+		case strategyObjectId:
+			id = primitive.NewObjectID().Hex()
+		case strategyUUIDv4:
+			id = uuid.NewString()
+		case strategyUUIDv7:
+			value, err := uuid.NewV7()
+			if err != nil {
+				return nil, err
+			}
+			id = value.String()
+		case strategyULID:
+			value, err := ulids.next(time.Now())
+			if err != nil {
+				return nil, err
+			}
+			id = value
+		case strategySnowflake:
+			id = strconv.FormatInt(snowflakes.next(nodeId), 10)
+		default:
+			return nil, fmt.Errorf("unknown id strategy %s, it could be one of %s", strategy, idStrategies)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// crockfordAlphabet is the base32 alphabet of ULID
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator generates monotonic ULIDs: within the same millisecond the random part is incremented
+type ulidGenerator struct {
+	mu       sync.Mutex
+	lastTime int64
+	random   *big.Int
+}
+
+var ulids = &ulidGenerator{}
+
+// maxULIDRandom is 2^80, the random part of a ULID has 80 bits
+var maxULIDRandom = new(big.Int).Lsh(big.NewInt(1), 80)
+
+func (g *ulidGenerator) next(now time.Time) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := now.UnixMilli()
+	if ms <= g.lastTime && g.random != nil {
+		ms = g.lastTime
+		g.random.Add(g.random, big.NewInt(1))
+		if g.random.Cmp(maxULIDRandom) >= 0 {
+			return "", fmt.Errorf("ulid random part overflow within one millisecond")
+		}
+	} else {
+		random, err := rand.Int(rand.Reader, maxULIDRandom)
+		if err != nil {
+			return "", err
+		}
+		g.random = random
+	}
+	g.lastTime = ms
+
+	// 48 bits of time followed by 80 bits of randomness, encoded as 26 base32 characters
+	value := new(big.Int).Lsh(big.NewInt(ms), 80)
+	value.Or(value, g.random)
+	encoded := make([]byte, 26)
+	mask := big.NewInt(31)
+	for i := len(encoded) - 1; i >= 0; i-- {
+		encoded[i] = crockfordAlphabet[new(big.Int).And(value, mask).Int64()]
+		value.Rsh(value, 5)
+	}
+	return string(encoded), nil
+}
+
+// snowflakeEpoch is the custom epoch of snowflake ids, 2024-01-01T00:00:00Z
+const snowflakeEpoch = int64(1704067200000)
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+)
+
+// snowflakeGenerator generates time sortable 63 bit ids: 41 bits of milliseconds, 10 bits of node id, 12 bits of sequence
+type snowflakeGenerator struct {
+	mu       sync.Mutex
+	lastTime int64
+	sequence int64
+}
+
+var snowflakes = &snowflakeGenerator{}
+
+func (g *snowflakeGenerator) next(nodeId int64) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := time.Now().UnixMilli() - snowflakeEpoch
+	if ms <= g.lastTime {
+		ms = g.lastTime
+		g.sequence = (g.sequence + 1) & (1<<snowflakeSequenceBits - 1)
+		if g.sequence == 0 {
+			// sequence exhausted for this millisecond, borrow the next one
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTime = ms
+
+	node := nodeId & (1<<snowflakeNodeBits - 1)
+	return ms<<(snowflakeNodeBits+snowflakeSequenceBits) | node<<snowflakeSequenceBits | g.sequence
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp/app/configs"
+)
+
+func TestFormatId(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity configs.EntityIdConfig
+		value  string
+		key    counterKey
+		want   string
+	}{
+		{
+			name:   "default template",
+			entity: withEntityDefaults(configs.EntityIdConfig{Prefix: "TSK"}),
+			value:  "0042",
+			want:   "TSK-0042",
+		},
+		{
+			name:   "date component",
+			entity: withEntityDefaults(configs.EntityIdConfig{Prefix: "TSK", DateFormat: "2006"}),
+			value:  "0001",
+			key:    counterKey{Period: "2026"},
+			want:   "TSK-2026-0001",
+		},
+		{
+			name:   "scope template",
+			entity: withEntityDefaults(configs.EntityIdConfig{Prefix: "LSN", Template: "{prefix}-{scope}-{seq}"}),
+			value:  "0003",
+			key:    counterKey{Scope: "course42"},
+			want:   "LSN-course42-0003",
+		},
+		{
+			name:   "custom separator",
+			entity: withEntityDefaults(configs.EntityIdConfig{Prefix: "ORD", Separator: "/"}),
+			value:  "01HZX",
+			want:   "ORD/01HZX",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatId(tt.entity, tt.value, tt.key); got != tt.want {
+				t.Errorf("formatId = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSequence(t *testing.T) {
+	entity := withEntityDefaults(configs.EntityIdConfig{Prefix: "TSK", Start: 100, Width: 5})
+	if got := formatSequence(entity, 1); got != "00100" {
+		t.Errorf("formatSequence = %s, want 00100", got)
+	}
+}
+
+func TestIdPattern(t *testing.T) {
+	tests := []struct {
+		name      string
+		entity    configs.EntityIdConfig
+		key       counterKey
+		matches   map[string]string
+		unmatched []string
+		wantErr   string
+	}{
+		{
+			name:      "default template",
+			entity:    withEntityDefaults(configs.EntityIdConfig{Prefix: "TSK"}),
+			matches:   map[string]string{"TSK-0042": "0042", "TSK-12345": "12345"},
+			unmatched: []string{"TSK-", "LSN-0001", "TSK-0042-x", "xTSK-0001"},
+		},
+		{
+			name:      "period of the counter",
+			entity:    withEntityDefaults(configs.EntityIdConfig{Prefix: "TSK", DateFormat: "2006"}),
+			key:       counterKey{Period: "2026"},
+			matches:   map[string]string{"TSK-2026-0007": "0007"},
+			unmatched: []string{"TSK-2025-0007", "TSK-0007"},
+		},
+		{
+			name:      "scope of the counter",
+			entity:    withEntityDefaults(configs.EntityIdConfig{Prefix: "LSN", Template: "{prefix}-{scope}-{seq}"}),
+			key:       counterKey{Scope: "c.1"},
+			matches:   map[string]string{"LSN-c.1-0003": "0003"},
+			unmatched: []string{"LSN-c21-0003", "LSN-other-0003"},
+		},
+		{
+			name:    "scope without placeholder",
+			entity:  withEntityDefaults(configs.EntityIdConfig{Prefix: "LSN"}),
+			key:     counterKey{Scope: "course42"},
+			wantErr: "no {scope} placeholder",
+		},
+		{
+			name:      "special characters of the prefix",
+			entity:    withEntityDefaults(configs.EntityIdConfig{Prefix: "A+B", Template: "{prefix}.{seq}"}),
+			matches:   map[string]string{"A+B.0001": "0001"},
+			unmatched: []string{"AAB.0001", "A+Bx0001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := idPattern(tt.entity, tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			re := regexp.MustCompile(pattern)
+			for id, sequence := range tt.matches {
+				match := re.FindStringSubmatch(id)
+				if match == nil || match[1] != sequence {
+					t.Errorf("%s on %s = %v, want sequence %s", pattern, id, match, sequence)
+				}
+			}
+			for _, id := range tt.unmatched {
+				if re.MatchString(id) {
+					t.Errorf("%s matches %s", pattern, id)
+				}
+			}
+		})
+	}
+}
+
+func TestIdPatternMatchesFormatId(t *testing.T) {
+	entity := withEntityDefaults(configs.EntityIdConfig{Prefix: "TSK", DateFormat: "2006-01", Template: "{prefix}/{scope}/{date}/{seq}"})
+	key := newCounterKey(entity, "tenant", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	pattern, err := idPattern(entity, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := formatId(entity, formatSequence(entity, 17), key)
+	if match := regexp.MustCompile(pattern).FindStringSubmatch(id); match == nil || match[1] != "0017" {
+		t.Errorf("%s on %s = %v, want sequence 0017", pattern, id, match)
+	}
+}
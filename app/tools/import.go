@@ -0,0 +1,536 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log"
+	"mcp/app/client"
+	"mcp/app/configs"
+	"mcp/app/model"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultImportDirectory = "imports"
+	// maxReportedFailures bounds the failed rows listed in the import report
+	maxReportedFailures = 20
+)
+
+// importTypes are the type hints of CSV columns
+var importTypes = []string{"auto", "string", "int", "long", "double", "decimal", "bool", "date", "objectId", "json"}
+
+type ImportTool interface {
+	// Import insert or update documents read from a file or inline content
+	Import() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type importTool struct {
+	Directory string
+}
+
+func NewImportTool(config configs.ImportConfig) ImportTool {
+	directory := config.Directory
+	if directory == "" {
+		directory = defaultImportDirectory
+	}
+	return &importTool{Directory: directory}
+}
+
+// importRow is a document read from the source, or the error parsing it
+type importRow struct {
+	Line int
+	Doc  bson.D
+	Err  error
+}
+
+// readJSONLines reads one Extended JSON document per line
+func readJSONLines(r io.Reader, emit func(importRow) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := importRow{Line: line}
+		row.Err = bson.UnmarshalExtJSON(text, false, &row.Doc)
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// lineCounter counts the lines of the data read through it, for offsets at or after the last one asked
+type lineCounter struct {
+	r        io.Reader
+	read     int64
+	newlines []int64
+	passed   int
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// line returns the line of an offset, offsets must not decrease between calls
+func (c *lineCounter) line(offset int64) int {
+	i := 0
+	for i < len(c.newlines) && c.newlines[i] < offset {
+		i++
+	}
+	c.passed += i
+	c.newlines = c.newlines[i:]
+	return c.passed + 1
+}
+
+// readExtJSON streams a JSON array of Extended JSON documents, a syntax error ends the import
+func readExtJSON(r io.Reader, emit func(importRow) error) error {
+	counter := &lineCounter{r: r}
+	decoder := json.NewDecoder(counter)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return fmt.Errorf("extjson content must be an array of documents")
+	}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			// a syntax error knows where it is, InputOffset stays at the end of the previous value
+			offset := decoder.InputOffset()
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				offset = syntaxErr.Offset
+			}
+			return fmt.Errorf("line %d: %v", counter.line(offset), err)
+		}
+		// InputOffset is the end of the value, its first byte is the line of the document
+		row := importRow{Line: counter.line(decoder.InputOffset() - int64(len(raw)))}
+		row.Err = bson.UnmarshalExtJSON(raw, false, &row.Doc)
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvCell converts a CSV cell with the type hint of its column
+func csvCell(cell string, hint string) (interface{}, error) {
+	switch hint {
+	case "string":
+		return cell, nil
+	case "int":
+		n, err := strconv.ParseInt(cell, 10, 32)
+		return int32(n), err
+	case "long":
+		return strconv.ParseInt(cell, 10, 64)
+	case "double":
+		return strconv.ParseFloat(cell, 64)
+	case "decimal":
+		return primitive.ParseDecimal128(cell)
+	case "bool":
+		return strconv.ParseBool(cell)
+	case "date":
+		if t, err := time.Parse(time.RFC3339Nano, cell); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, cell)
+	case "objectId":
+		return primitive.ObjectIDFromHex(cell)
+	case "json":
+		var wrapper struct {
+			Value interface{} `bson:"value"`
+		}
+		err := bson.UnmarshalExtJSON([]byte(`{"value":`+cell+`}`), false, &wrapper)
+		return wrapper.Value, err
+	}
+
+	// auto only converts values formatted back identically, so zip codes like 01234 stay strings.
+	// ObjectIds and dates are recognized in the hex and RFC 3339 forms written by export
+	if n, err := strconv.ParseInt(cell, 10, 64); err == nil && strconv.FormatInt(n, 10) == cell {
+		if n == int64(int32(n)) {
+			return int32(n), nil
+		}
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == cell {
+		return f, nil
+	}
+	if cell == "true" || cell == "false" {
+		return cell == "true", nil
+	}
+	if len(cell) == 24 {
+		if oid, err := primitive.ObjectIDFromHex(cell); err == nil {
+			return oid, nil
+		}
+	}
+	if len(cell) >= len("2006-01-02T15:04:05Z") && cell[10] == 'T' {
+		if t, err := time.Parse(time.RFC3339Nano, cell); err == nil {
+			return t, nil
+		}
+	}
+	if strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{") {
+		if value, err := csvCell(cell, "json"); err == nil {
+			return value, nil
+		}
+	}
+	return cell, nil
+}
+
+// setPath sets the value of a dotted path, creating the nested documents
+func setPath(doc bson.D, path []string, value interface{}) bson.D {
+	for i, e := range doc {
+		if e.Key == path[0] {
+			if len(path) > 1 {
+				nested, _ := e.Value.(bson.D)
+				doc[i].Value = setPath(nested, path[1:], value)
+			} else {
+				doc[i].Value = value
+			}
+			return doc
+		}
+	}
+	if len(path) > 1 {
+		return append(doc, bson.E{Key: path[0], Value: setPath(bson.D{}, path[1:], value)})
+	}
+	return append(doc, bson.E{Key: path[0], Value: value})
+}
+
+// readCSV reads a header of dotted paths then one document per row, blank cells are left out
+func readCSV(r io.Reader, types map[string]string, emit func(importRow) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read csv header failed: %v", err)
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err = emit(importRow{Line: parseErr.Line, Err: parseErr.Err}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{Line: line, Doc: bson.D{}}
+		if len(record) > len(headers) {
+			row.Err = fmt.Errorf("%d cells for %d columns", len(record), len(headers))
+		}
+		for i := 0; i < len(record) && row.Err == nil; i++ {
+			if record[i] == "" {
+				continue
+			}
+			value, err := csvCell(record[i], types[headers[i]])
+			if err != nil {
+				row.Err = fmt.Errorf("column %s: %v", headers[i], err)
+				break
+			}
+			row.Doc = setPath(row.Doc, strings.Split(headers[i], "."), value)
+		}
+		if err = emit(row); err != nil {
+			return err
+		}
+	}
+}
+
+// lookupPath returns the value of a dotted path of a document
+func lookupPath(doc bson.D, path string) (interface{}, bool) {
+	keys := strings.Split(path, ".")
+	var value interface{} = doc
+	for _, key := range keys {
+		nested, ok := value.(bson.D)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = lookup(nested, key); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// appendSetPaths appends the leaves of a value to a $set by dotted path, so the fields of a nested
+// document missing from the row are kept. Empty documents are set as they are
+func appendSetPaths(set bson.D, path string, value interface{}) bson.D {
+	nested, ok := value.(bson.D)
+	if !ok || len(nested) == 0 {
+		return append(set, bson.E{Key: path, Value: value})
+	}
+	for _, e := range nested {
+		set = appendSetPaths(set, path+"."+e.Key, e.Value)
+	}
+	return set
+}
+
+// importWriteModel builds the write of a document for the import mode
+func importWriteModel(doc bson.D, mode string, key []string) (mongo.WriteModel, error) {
+	if mode == "insert" {
+		return mongo.NewInsertOneModel().SetDocument(doc), nil
+	}
+	filter := bson.D{}
+	for _, field := range key {
+		value, ok := lookupPath(doc, field)
+		if !ok {
+			return nil, fmt.Errorf("missing key field %s", field)
+		}
+		filter = append(filter, bson.E{Key: field, Value: value})
+	}
+	if mode == "upsert" {
+		return mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc).SetUpsert(true), nil
+	}
+
+	// merge keeps the fields missing from the row, _id is immutable so it's only set on insert
+	set := bson.D{}
+	update := bson.D{}
+	for _, e := range doc {
+		if e.Key == "_id" {
+			update = append(update, bson.E{Key: "$setOnInsert", Value: bson.D{e}})
+		} else {
+			set = appendSetPaths(set, e.Key, e.Value)
+		}
+	}
+	if len(set) > 0 {
+		update = append(update, bson.E{Key: "$set", Value: set})
+	}
+	if len(update) == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+	return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true), nil
+}
+
+// importReport counts the rows of an import
+type importReport struct {
+	Read      int
+	Inserted  int64
+	Updated   int64
+	Unchanged int64
+	Failed    int
+	Failures  []string
+}
+
+func (r *importReport) fail(line int, err error) {
+	r.Failed++
+	if len(r.Failures) < maxReportedFailures {
+		r.Failures = append(r.Failures, fmt.Sprintf("line %d: %v", line, err))
+	}
+}
+
+func (r *importReport) String() string {
+	result := fmt.Sprintf("Read %d rows, inserted %d, updated %d, unchanged %d, failed %d",
+		r.Read, r.Inserted, r.Updated, r.Unchanged, r.Failed)
+	for _, failure := range r.Failures {
+		result += "\n  " + failure
+	}
+	if r.Failed > len(r.Failures) {
+		result += fmt.Sprintf("\n  ... and %d more", r.Failed-len(r.Failures))
+	}
+	return result
+}
+
+// importBatch is the pending writes of an import and the lines they come from
+type importBatch struct {
+	coll   *mongo.Collection
+	models []mongo.WriteModel
+	lines  []int
+	report *importReport
+}
+
+// flush writes the pending batch unordered, so a failed row doesn't stop the others
+func (b *importBatch) flush(ctx context.Context) error {
+	if len(b.models) == 0 {
+		return nil
+	}
+	result, err := b.coll.BulkWrite(ctx, b.models, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bulkErr) {
+		return err
+	}
+	b.report.Inserted += result.InsertedCount + result.UpsertedCount
+	b.report.Updated += result.ModifiedCount
+	b.report.Unchanged += result.MatchedCount - result.ModifiedCount
+	for _, writeErr := range bulkErr.WriteErrors {
+		b.report.fail(b.lines[writeErr.Index], errors.New(writeErr.Message))
+	}
+	b.models, b.lines = b.models[:0], b.lines[:0]
+	return nil
+}
+
+// Import insert or update documents read from a file or inline content
+func (i importTool) Import() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"Import",
+		mcp.WithDescription("Insert or update documents read from a file of the import directory or from inline content, "+
+			"in batches, reporting the inserted, updated and failed rows with their line numbers"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("filename",
+			mcp.Description("Name of a file of the import directory"),
+		),
+		mcp.WithString("content",
+			mcp.Description("Inline content to import instead of a file"),
+		),
+		mcp.WithString("format",
+			mcp.Description("jsonl reads one Extended JSON document per line, csv a header of dotted paths then one row per document, "+
+				"extjson an array of Extended JSON documents. Defaults to the extension of the file, jsonl for inline content"),
+			mcp.Enum("jsonl", "csv", "extjson"),
+		),
+		mcp.WithObject("types",
+			mcp.Description("Type hint of CSV columns by header, one of auto, string, int, long, double, decimal, bool, "+
+				"date, objectId, json (e.g., {\"zip\": \"string\", \"createdAt\": \"date\"}). "+
+				"auto converts numbers, booleans, 24 hex digit ObjectIds and RFC 3339 dates, as written by export; "+
+				"use string to keep such columns as text. Blank cells are left out"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("insert adds new documents, upsert replaces the documents matching the key or inserts them, "+
+				"merge sets the imported fields, nested ones by dotted path, of the documents matching the key or inserts them"),
+			mcp.Enum("insert", "upsert", "merge"),
+			mcp.DefaultString("insert"),
+		),
+		mcp.WithArray("key",
+			mcp.Description("Fields identifying a document in upsert and merge modes, defaults to _id"),
+			mcp.Items(map[string]interface{}{"type": "string"}),
+		),
+		mcp.WithNumber("batch_size",
+			mcp.Description("Number of documents written per batch"),
+			mcp.DefaultNumber(1000),
+			mcp.Min(1),
+			mcp.Max(10000),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ImportRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.Mode == "" {
+			req.Mode = "insert"
+		}
+		if req.Mode != "insert" && req.Mode != "upsert" && req.Mode != "merge" {
+			return mcp.NewToolResultText(fmt.Sprintf("unknown mode %s, use insert, upsert or merge", req.Mode)), nil
+		}
+		if len(req.Key) == 0 {
+			req.Key = []string{"_id"}
+		}
+		if req.BatchSize <= 0 {
+			req.BatchSize = 1000
+		}
+		for column, hint := range req.Types {
+			if !slices.Contains(importTypes, hint) {
+				return mcp.NewToolResultText(fmt.Sprintf("unknown type %s of column %s, use one of %v", hint, column, importTypes)), nil
+			}
+		}
+
+		var source io.Reader
+		switch {
+		case req.Filename != "" && req.Content != "":
+			return mcp.NewToolResultText("Pass either filename or content, not both"), nil
+		case req.Filename != "":
+			path, err := directoryFile(i.Directory, req.Filename)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("open %s failed: %v", req.Filename, err)), nil
+			}
+			defer file.Close()
+			source = file
+			if req.Format == "" {
+				for name, format := range exportFormats {
+					if filepath.Ext(req.Filename) == format.Extension {
+						req.Format = name
+					}
+				}
+			}
+		case req.Content != "":
+			source = strings.NewReader(req.Content)
+		default:
+			return mcp.NewToolResultText("filename or content is required"), nil
+		}
+		if req.Format == "" {
+			req.Format = "jsonl"
+		}
+
+		log.Printf("Import %s into collection %s, format: %s, mode: %s", req.Filename, req.Collection, req.Format, req.Mode)
+
+		report := &importReport{}
+		batch := &importBatch{coll: client.DB.Collection(req.Collection), report: report}
+		emit := func(row importRow) error {
+			report.Read++
+			if row.Err != nil {
+				report.fail(row.Line, row.Err)
+				return nil
+			}
+			writeModel, err := importWriteModel(row.Doc, req.Mode, req.Key)
+			if err != nil {
+				report.fail(row.Line, err)
+				return nil
+			}
+			batch.models = append(batch.models, writeModel)
+			batch.lines = append(batch.lines, row.Line)
+			if len(batch.models) >= req.BatchSize {
+				return batch.flush(ctx)
+			}
+			return nil
+		}
+
+		switch req.Format {
+		case "csv":
+			err = readCSV(source, req.Types, emit)
+		case "extjson":
+			err = readExtJSON(source, emit)
+		case "jsonl":
+			err = readJSONLines(source, emit)
+		default:
+			return mcp.NewToolResultText(fmt.Sprintf("unknown format %s, use jsonl, csv or extjson", req.Format)), nil
+		}
+		if err == nil {
+			err = batch.flush(ctx)
+		}
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("Import stopped: %v\n%s", err, report)), err
+		}
+		return mcp.NewToolResultText(report.String()), nil
+	}
+	return
+}
@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCsvCell(t *testing.T) {
+	oid, _ := primitive.ObjectIDFromHex("65f1c0ffee0000000000beef")
+	date := time.Date(2026, 10, 19, 8, 30, 0, 500000000, time.UTC)
+	decimal, _ := primitive.ParseDecimal128("12.50")
+	tests := []struct {
+		name    string
+		cell    string
+		hint    string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "auto int", cell: "42", want: int32(42)},
+		{name: "auto long", cell: "4294967296", want: int64(4294967296)},
+		{name: "auto double", cell: "1.5", want: 1.5},
+		{name: "auto keeps leading zeros", cell: "01234", want: "01234"},
+		{name: "auto keeps trailing zeros", cell: "1.50", want: "1.50"},
+		{name: "auto bool", cell: "true", want: true},
+		{name: "auto objectId", cell: "65f1c0ffee0000000000beef", want: oid},
+		{name: "auto date", cell: "2026-10-19T08:30:00.5Z", want: date},
+		{name: "auto date only stays string", cell: "2026-10-19", want: "2026-10-19"},
+		{name: "auto 24 characters not hex", cell: "65f1c0ffee0000000000beez", want: "65f1c0ffee0000000000beez"},
+		{name: "auto json array", cell: `[1,"a"]`, want: bson.A{int32(1), "a"}},
+		{name: "auto invalid json stays string", cell: "[not json", want: "[not json"},
+		{name: "auto text", cell: "hello", want: "hello"},
+		{name: "string hint", cell: "65f1c0ffee0000000000beef", hint: "string", want: "65f1c0ffee0000000000beef"},
+		{name: "int hint", cell: "7", hint: "int", want: int32(7)},
+		{name: "int hint overflow", cell: "4294967296", hint: "int", wantErr: true},
+		{name: "long hint", cell: "7", hint: "long", want: int64(7)},
+		{name: "double hint", cell: "7", hint: "double", want: 7.0},
+		{name: "decimal hint", cell: "12.50", hint: "decimal", want: decimal},
+		{name: "bool hint", cell: "1", hint: "bool", want: true},
+		{name: "date hint", cell: "2026-10-19", hint: "date", want: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{name: "date hint invalid", cell: "19/10/2026", hint: "date", wantErr: true},
+		{name: "objectId hint invalid", cell: "abc", hint: "objectId", wantErr: true},
+		{name: "json hint", cell: `{"$numberLong": "5"}`, hint: "json", want: int64(5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := csvCell(tt.cell, tt.hint)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("csvCell = %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotTime, ok := got.(time.Time); ok {
+				if !gotTime.Equal(tt.want.(time.Time)) {
+					t.Errorf("csvCell = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if !equalValues(got, tt.want) {
+				t.Errorf("csvCell = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// equalValues compares values by their Extended JSON, which tells BSON types apart
+func equalValues(a interface{}, b interface{}) bool {
+	wrap := func(v interface{}) string {
+		data, _ := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, true, false)
+		return string(data)
+	}
+	return wrap(a) == wrap(b)
+}
+
+func TestSetPath(t *testing.T) {
+	doc := bson.D{}
+	doc = setPath(doc, []string{"name"}, "Ann")
+	doc = setPath(doc, []string{"address", "city"}, "Paris")
+	doc = setPath(doc, []string{"address", "zip"}, "75001")
+	doc = setPath(doc, []string{"address", "geo", "lat"}, 48.8)
+	doc = setPath(doc, []string{"name"}, "Bob")
+	want := bson.D{
+		{Key: "name", Value: "Bob"},
+		{Key: "address", Value: bson.D{
+			{Key: "city", Value: "Paris"},
+			{Key: "zip", Value: "75001"},
+			{Key: "geo", Value: bson.D{{Key: "lat", Value: 48.8}}},
+		}},
+	}
+	if !equalValues(doc, want) {
+		t.Errorf("setPath = %v, want %v", doc, want)
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	doc := bson.D{{Key: "a", Value: bson.D{{Key: "b", Value: int32(1)}}}, {Key: "c", Value: "x"}}
+	if value, ok := lookupPath(doc, "a.b"); !ok || value != int32(1) {
+		t.Errorf("a.b = %v, %t", value, ok)
+	}
+	for _, path := range []string{"a.c", "c.d", "z"} {
+		if value, ok := lookupPath(doc, path); ok {
+			t.Errorf("%s = %v, want missing", path, value)
+		}
+	}
+}
+
+func TestReadExtJSON(t *testing.T) {
+	content := "[\n" +
+		"  {\"_id\": 1},\n" +
+		"  {\"_id\": {\"$oid\": \"65f1c0ffee0000000000beef\"},\n" +
+		"   \"name\": \"multi line\"},\n" +
+		"\n" +
+		"  {\"_id\": {\"$numberLong\": \"x\"}}, {\"_id\": 4}\n" +
+		"]"
+	var rows []importRow
+	// one byte reads check the line numbers don't depend on the buffering of the decoder
+	err := readExtJSON(iotest.OneByteReader(strings.NewReader(content)), func(row importRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLines := []int{2, 3, 6, 6}
+	if len(rows) != len(wantLines) {
+		t.Fatalf("read %d rows, want %d", len(rows), len(wantLines))
+	}
+	for i, row := range rows {
+		if row.Line != wantLines[i] {
+			t.Errorf("row %d on line %d, want %d", i, row.Line, wantLines[i])
+		}
+		if (row.Err != nil) != (i == 2) {
+			t.Errorf("row %d error = %v", i, row.Err)
+		}
+	}
+}
+
+func TestReadExtJSONSyntaxError(t *testing.T) {
+	err := readExtJSON(strings.NewReader("[\n{\"a\": 1},\n{\"a\": }\n]"), func(importRow) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error = %v, want a syntax error on line 3", err)
+	}
+	err = readExtJSON(strings.NewReader(`{"a": 1}`), func(importRow) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "must be an array") {
+		t.Errorf("error = %v, want an array error", err)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	content := "_id,address.city,address.zip,total\n" +
+		"65f1c0ffee0000000000beef,Paris,01234,12\n" +
+		"2,\"Saint-\nDenis\",,x\n" +
+		"3,Lyon,69001,1,extra\n"
+	var rows []importRow
+	err := readCSV(strings.NewReader(content), map[string]string{"total": "int"}, func(row importRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("read %d rows, want 3", len(rows))
+	}
+	oid, _ := primitive.ObjectIDFromHex("65f1c0ffee0000000000beef")
+	want := bson.D{
+		{Key: "_id", Value: oid},
+		{Key: "address", Value: bson.D{{Key: "city", Value: "Paris"}, {Key: "zip", Value: "01234"}}},
+		{Key: "total", Value: int32(12)},
+	}
+	if rows[0].Line != 2 || rows[0].Err != nil || !equalValues(rows[0].Doc, want) {
+		t.Errorf("row 0 = %+v, want %v on line 2", rows[0], want)
+	}
+	if rows[1].Line != 3 || rows[1].Err == nil || !strings.Contains(rows[1].Err.Error(), "column total") {
+		t.Errorf("row 1 = %+v, want a total error on line 3", rows[1])
+	}
+	if rows[2].Line != 5 || rows[2].Err == nil {
+		t.Errorf("row 2 = %+v, want a cell count error on line 5", rows[2])
+	}
+}
+
+func TestImportWriteModel(t *testing.T) {
+	doc := bson.D{
+		{Key: "_id", Value: int32(1)},
+		{Key: "sku", Value: "A1"},
+		{Key: "address", Value: bson.D{{Key: "city", Value: "Paris"}, {Key: "geo", Value: bson.D{{Key: "lat", Value: 48.8}}}}},
+		{Key: "tags", Value: bson.D{}},
+	}
+
+	model, err := importWriteModel(doc, "merge", []string{"sku"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update, ok := model.(*mongo.UpdateOneModel)
+	if !ok {
+		t.Fatalf("merge model is %T", model)
+	}
+	wantUpdate := bson.D{
+		{Key: "$setOnInsert", Value: bson.D{{Key: "_id", Value: int32(1)}}},
+		{Key: "$set", Value: bson.D{
+			{Key: "sku", Value: "A1"},
+			{Key: "address.city", Value: "Paris"},
+			{Key: "address.geo.lat", Value: 48.8},
+			{Key: "tags", Value: bson.D{}},
+		}},
+	}
+	if !equalValues(update.Update, wantUpdate) {
+		t.Errorf("merge update = %v, want %v", update.Update, wantUpdate)
+	}
+	if !equalValues(update.Filter, bson.D{{Key: "sku", Value: "A1"}}) || update.Upsert == nil || !*update.Upsert {
+		t.Errorf("merge filter = %v, upsert = %v", update.Filter, update.Upsert)
+	}
+
+	model, err = importWriteModel(doc, "upsert", []string{"address.city"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replace, ok := model.(*mongo.ReplaceOneModel)
+	if !ok || !equalValues(replace.Filter, bson.D{{Key: "address.city", Value: "Paris"}}) {
+		t.Errorf("upsert model = %#v", model)
+	}
+
+	if _, err = importWriteModel(doc, "merge", []string{"missing"}); err == nil {
+		t.Error("missing key field, want an error")
+	}
+	if model, err = importWriteModel(doc, "insert", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = model.(*mongo.InsertOneModel); !ok {
+		t.Errorf("insert model is %T", model)
+	}
+}
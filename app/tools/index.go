@@ -8,8 +8,10 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"mcp/app/client"
 	"mcp/app/model"
+	"strings"
 )
 
 type IndexTool interface {
@@ -19,6 +21,20 @@ type IndexTool interface {
 	CreateIndex() (mcp.Tool, server.ToolHandlerFunc)
 	// DropIndex drop index in mongodb
 	DropIndex() (mcp.Tool, server.ToolHandlerFunc)
+	// CreateIndexes create several indexes in background
+	CreateIndexes() (mcp.Tool, server.ToolHandlerFunc)
+	// IndexBuildProgress report in-progress index builds
+	IndexBuildProgress() (mcp.Tool, server.ToolHandlerFunc)
+	// IndexUsage report index usage statistics and cleanup candidates
+	IndexUsage() (mcp.Tool, server.ToolHandlerFunc)
+	// SuggestIndexes propose indexes for query shapes
+	SuggestIndexes() (mcp.Tool, server.ToolHandlerFunc)
+	// HideIndex hide index from the query planner
+	HideIndex() (mcp.Tool, server.ToolHandlerFunc)
+	// UnhideIndex make a hidden index visible to the query planner
+	UnhideIndex() (mcp.Tool, server.ToolHandlerFunc)
+	// ModifyIndex change TTL or convert index to unique
+	ModifyIndex() (mcp.Tool, server.ToolHandlerFunc)
 }
 
 type indexTool struct{}
@@ -48,13 +64,19 @@ func (c indexTool) ListIndexes() (tool mcp.Tool, handler server.ToolHandlerFunc)
 		}
 		defer cur.Close(ctx)
 
-		var indexes []bson.M
+		var indexes []bson.Raw
 		if err = cur.All(ctx, &indexes); err != nil {
 			return mcp.NewToolResultText(err.Error()), err
 		}
 		var result string
 		for _, doc := range indexes {
-			result += fmt.Sprintf("%v\n", doc)
+			// hidden is only present on hidden indexes
+			hidden, _ := doc.Lookup("hidden").BooleanOK()
+			spec, err := bson.MarshalExtJSON(doc, false, false)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			result += fmt.Sprintf("name: %s, hidden: %t, spec: %s\n", doc.Lookup("name").StringValue(), hidden, spec)
 		}
 		return mcp.NewToolResultText(result), nil
 
@@ -74,8 +96,50 @@ func (c indexTool) CreateIndex() (tool mcp.Tool, handler server.ToolHandlerFunc)
 			mcp.Description("Collection name"),
 		),
 		mcp.WithObject("index_spec",
-			mcp.Required(),
-			mcp.Description("Index specification (e.g., { field: 1 } for ascending index)"),
+			mcp.Description("Index specification (e.g., { field: 1 } for ascending index), "+
+				"the key order of a compound index may be lost, use keys to keep it"),
+		),
+		mcp.WithString("keys",
+			mcp.Description("Index keys as Extended JSON, the key order is kept (e.g., {\"status\": 1, \"createdAt\": -1})"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Index name, generated from the keys if empty"),
+		),
+		mcp.WithBoolean("unique",
+			mcp.Description("Reject documents with duplicate key values"),
+		),
+		mcp.WithBoolean("sparse",
+			mcp.Description("Only index documents containing the indexed fields"),
+		),
+		mcp.WithBoolean("hidden",
+			mcp.Description("Create the index hidden from the query planner"),
+		),
+		mcp.WithObject("partial_filter_expression",
+			mcp.Description("Only index documents matching this filter"),
+		),
+		mcp.WithNumber("expire_after_seconds",
+			mcp.Description("TTL in seconds, documents expire this long after the date in the indexed field"),
+			mcp.Min(0),
+		),
+		mcp.WithObject("collation",
+			mcp.Description("Collation of the index (e.g., { locale: 'en', strength: 2 })"),
+		),
+		mcp.WithObject("wildcard_projection",
+			mcp.Description("Fields included or excluded by a wildcard index ($**)"),
+		),
+		mcp.WithObject("weights",
+			mcp.Description("Weights of the fields of a text index"),
+		),
+		mcp.WithString("default_language",
+			mcp.Description("Default language of a text index"),
+		),
+		mcp.WithString("language_override",
+			mcp.Description("Field holding the language of each document for a text index"),
+		),
+		mcp.WithNumber("sphere_version",
+			mcp.Description("Version of a 2dsphere index"),
+			mcp.Min(1),
+			mcp.Max(3),
 		),
 	)
 	// handler
@@ -87,18 +151,79 @@ func (c indexTool) CreateIndex() (tool mcp.Tool, handler server.ToolHandlerFunc)
 			return mcp.NewToolResultText(err.Error()), err
 		}
 
+		var keys interface{}
+		var warning string
+		switch {
+		case req.Keys != "":
+			keys, err = parseDocument(req.Keys)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+		case len(req.IndexSpec) > 0:
+			keys = req.IndexSpec
+			if len(req.IndexSpec) > 1 {
+				// JSON objects are decoded into maps, which lose the order of the compound keys
+				warning = "\nWarning: index_spec may not keep the key order of a compound index, pass keys as Extended JSON to keep it"
+			}
+		default:
+			return mcp.NewToolResultText("One of keys or index_spec is required"), nil
+		}
+
 		res, err := client.DB.Collection(req.Collection).Indexes().CreateOne(ctx, mongo.IndexModel{
-			Keys: req.IndexSpec,
+			Keys:    keys,
+			Options: indexOptions(req),
 		})
 		if err != nil {
 			return mcp.NewToolResultText(err.Error()), err
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Index created, Name: %v", res)), nil
+		return mcp.NewToolResultText(fmt.Sprintf("Index created, Name: %v", res) + warning), nil
 	}
 	return
 }
 
+// indexOptions converts the options of a create index request
+func indexOptions(req model.CreateIndexRequest) *options.IndexOptions {
+	opts := options.Index()
+	if req.Name != "" {
+		opts.SetName(req.Name)
+	}
+	if req.Unique {
+		opts.SetUnique(true)
+	}
+	if req.Sparse {
+		opts.SetSparse(true)
+	}
+	if req.Hidden {
+		opts.SetHidden(true)
+	}
+	if req.PartialFilterExpression != nil {
+		opts.SetPartialFilterExpression(req.PartialFilterExpression)
+	}
+	if req.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*req.ExpireAfterSeconds)
+	}
+	if req.Collation != nil {
+		opts.SetCollation(req.Collation)
+	}
+	if req.WildcardProjection != nil {
+		opts.SetWildcardProjection(req.WildcardProjection)
+	}
+	if req.Weights != nil {
+		opts.SetWeights(req.Weights)
+	}
+	if req.DefaultLanguage != "" {
+		opts.SetDefaultLanguage(req.DefaultLanguage)
+	}
+	if req.LanguageOverride != "" {
+		opts.SetLanguageOverride(req.LanguageOverride)
+	}
+	if req.SphereVersion > 0 {
+		opts.SetSphereVersion(req.SphereVersion)
+	}
+	return opts
+}
+
 // DropIndex drop index in mongodb
 func (c indexTool) DropIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 
@@ -110,7 +235,7 @@ func (c indexTool) DropIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 			mcp.Required(),
 			mcp.Description("Collection name"),
 		),
-		mcp.WithObject("index_name",
+		mcp.WithString("index_name",
 			mcp.Required(),
 			mcp.Description("Name of the index to drop"),
 		),
@@ -132,3 +257,144 @@ func (c indexTool) DropIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	}
 	return
 }
+
+// setIndexHidden hides or unhides an index with collMod
+func setIndexHidden(ctx context.Context, collection string, name string, hidden bool) error {
+	return modifyIndexOption(ctx, collection, name, "hidden", hidden)
+}
+
+// HideIndex hide index from the query planner
+func (c indexTool) HideIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"HideIndex",
+		mcp.WithDescription("hide an index from the query planner to test the impact of dropping it, "+
+			"the index is still maintained and can be unhidden instantly"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("index_name",
+			mcp.Required(),
+			mcp.Description("Name of the index to hide"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.HideIndexRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		if err = setIndexHidden(ctx, req.Collection, req.IndexName, true); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Index %s hidden", req.IndexName)), nil
+	}
+	return
+}
+
+// UnhideIndex make a hidden index visible to the query planner
+func (c indexTool) UnhideIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"UnhideIndex",
+		mcp.WithDescription("make a hidden index visible to the query planner again"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("index_name",
+			mcp.Required(),
+			mcp.Description("Name of the index to unhide"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.HideIndexRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		if err = setIndexHidden(ctx, req.Collection, req.IndexName, false); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Index %s unhidden", req.IndexName)), nil
+	}
+	return
+}
+
+// modifyIndexOption sets an option of an index with collMod
+func modifyIndexOption(ctx context.Context, collection string, name string, option string, value interface{}) error {
+	index := bson.D{{Key: "name", Value: name}, {Key: option, Value: value}}
+	return client.DB.RunCommand(ctx, bson.D{{Key: "collMod", Value: collection}, {Key: "index", Value: index}}).Err()
+}
+
+// ModifyIndex change TTL or convert index to unique
+func (c indexTool) ModifyIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ModifyIndex",
+		mcp.WithDescription("change the TTL of an index or convert it to a unique index with collMod"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("index_name",
+			mcp.Required(),
+			mcp.Description("Name of the index to modify"),
+		),
+		mcp.WithNumber("expire_after_seconds",
+			mcp.Description("New TTL in seconds of a TTL index"),
+			mcp.Min(0),
+		),
+		mcp.WithBoolean("unique",
+			mcp.Description("Convert the index to unique, fails if the collection holds duplicate keys"),
+			mcp.DefaultBool(false),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ModifyIndexRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if req.ExpireAfterSeconds == nil && !req.Unique {
+			return mcp.NewToolResultText("Nothing to modify, set expire_after_seconds or unique"), nil
+		}
+
+		var changes []string
+		if req.ExpireAfterSeconds != nil {
+			if err = modifyIndexOption(ctx, req.Collection, req.IndexName, "expireAfterSeconds", *req.ExpireAfterSeconds); err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			changes = append(changes, fmt.Sprintf("expireAfterSeconds set to %d", *req.ExpireAfterSeconds))
+		}
+		if req.Unique {
+			// prepareUnique rejects new duplicates first, then the conversion checks the existing keys
+			if err = modifyIndexOption(ctx, req.Collection, req.IndexName, "prepareUnique", true); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("prepareUnique failed: %v", err)), err
+			}
+			if err = modifyIndexOption(ctx, req.Collection, req.IndexName, "unique", true); err != nil {
+				// existing duplicates make the conversion fail, stop rejecting new ones
+				if rollbackErr := modifyIndexOption(ctx, req.Collection, req.IndexName, "prepareUnique", false); rollbackErr != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("unique failed: %v, and resetting prepareUnique failed: %v, "+
+						"the index now rejects new duplicate keys", err, rollbackErr)), err
+				}
+				return mcp.NewToolResultText(fmt.Sprintf("unique failed: %v, prepareUnique reset to false", err)), err
+			}
+			changes = append(changes, "converted to unique")
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Index %s modified: %s", req.IndexName, strings.Join(changes, ", "))), nil
+	}
+	return
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexBuild is a createIndexes command running in background
+type indexBuild struct {
+	ID         string
+	Collection string
+	Names      []string
+	Started    time.Time
+	Finished   time.Time
+	Err        error
+}
+
+func (b indexBuild) status() string {
+	switch {
+	case b.Finished.IsZero():
+		return "running"
+	case b.Err != nil:
+		return "failed: " + b.Err.Error()
+	default:
+		return "done"
+	}
+}
+
+type indexBuildRegistry struct {
+	mu     sync.Mutex
+	builds map[string]*indexBuild
+}
+
+var indexBuilds = &indexBuildRegistry{builds: map[string]*indexBuild{}}
+
+// finishedIndexBuildTTL is how long the outcome of a finished build stays available to IndexBuildProgress
+const finishedIndexBuildTTL = time.Hour
+
+// add registers a build and evicts the builds finished for longer than finishedIndexBuildTTL
+func (r *indexBuildRegistry) add(build *indexBuild) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, b := range r.builds {
+		if !b.Finished.IsZero() && time.Since(b.Finished) > finishedIndexBuildTTL {
+			delete(r.builds, id)
+		}
+	}
+	r.builds[build.ID] = build
+}
+
+func (r *indexBuildRegistry) finish(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.builds[id].Finished = time.Now()
+	r.builds[id].Err = err
+}
+
+// get returns a copy of the build, so it can be read while the build goes on
+func (r *indexBuildRegistry) get(id string) (indexBuild, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	build, ok := r.builds[id]
+	if !ok {
+		return indexBuild{}, false
+	}
+	return *build, true
+}
+
+// indexName generates the default name of an index the way mongod does, e.g. status_1_createdAt_-1
+func indexName(keys bson.D) string {
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// CreateIndexes create several indexes in background
+func (c indexTool) CreateIndexes() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CreateIndexes",
+		mcp.WithDescription("create several indexes in one call. The build runs in background, "+
+			"the returned operation_id can be passed to IndexBuildProgress"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("indexes",
+			mcp.Required(),
+			mcp.Description("Index specifications as an Extended JSON array, with the fields of the createIndexes command "+
+				"(e.g., [{\"key\": {\"status\": 1, \"createdAt\": -1}, \"name\": \"status_createdAt\", \"unique\": false}])"),
+		),
+		mcp.WithString("commit_quorum",
+			mcp.Description("Number of data-bearing voting members which must be ready to commit the build, "+
+				"a number, \"majority\" or \"votingMembers\""),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CreateIndexesRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		specs, err := parseDocuments(req.Indexes)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("parse indexes failed: %v", err)), nil
+		}
+		if len(specs) == 0 {
+			return mcp.NewToolResultText("No index specified"), nil
+		}
+		var names []string
+		for i, spec := range specs {
+			value, _ := lookup(spec, "key")
+			keys, ok := value.(bson.D)
+			if !ok {
+				return mcp.NewToolResultText(fmt.Sprintf("index %d has no key document", i)), nil
+			}
+			value, _ = lookup(spec, "name")
+			name, ok := value.(string)
+			if !ok {
+				name = indexName(keys)
+				specs[i] = append(spec, bson.E{Key: "name", Value: name})
+			}
+			names = append(names, name)
+		}
+
+		command := bson.D{{Key: "createIndexes", Value: req.Collection}, {Key: "indexes", Value: specs}}
+		if req.CommitQuorum != "" {
+			if quorum, err := strconv.Atoi(req.CommitQuorum); err == nil {
+				command = append(command, bson.E{Key: "commitQuorum", Value: quorum})
+			} else {
+				command = append(command, bson.E{Key: "commitQuorum", Value: req.CommitQuorum})
+			}
+		}
+
+		build := &indexBuild{
+			ID:         primitive.NewObjectID().Hex(),
+			Collection: req.Collection,
+			Names:      names,
+			Started:    time.Now(),
+		}
+		indexBuilds.add(build)
+		log.Printf("Index build %s started on %s: %s", build.ID, req.Collection, names)
+
+		// the build outlives the tool call
+		go func() {
+			err := client.DB.RunCommand(context.Background(), command).Err()
+			if err != nil {
+				log.Printf("Index build %s failed: %v", build.ID, err)
+			}
+			indexBuilds.finish(build.ID, err)
+		}()
+
+		return mcp.NewToolResultText(
+			fmt.Sprintf("Index build started, operation_id: %s, indexes: %s", build.ID, strings.Join(names, ", ")),
+		), nil
+	}
+	return
+}
+
+// IndexBuildProgress report in-progress index builds
+func (c indexTool) IndexBuildProgress() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"IndexBuildProgress",
+		mcp.WithDescription("report in-progress index builds with their percentage complete"),
+		mcp.WithString("collection",
+			mcp.Description("Only report builds of this collection"),
+		),
+		mcp.WithString("operation_id",
+			mcp.Description("Operation id returned by CreateIndexes, finished builds are kept for an hour"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.IndexBuildProgressRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		var result string
+		if req.OperationID != "" {
+			build, ok := indexBuilds.get(req.OperationID)
+			if !ok {
+				return mcp.NewToolResultText(fmt.Sprintf("operation %s not found or finished more than an hour ago", req.OperationID)), nil
+			}
+			result += fmt.Sprintf("Operation %s on %s, indexes: %s, status: %s",
+				build.ID, build.Collection, strings.Join(build.Names, ", "), build.status())
+			if !build.Finished.IsZero() {
+				result += fmt.Sprintf(", took: %s\n", build.Finished.Sub(build.Started).Round(time.Second))
+				return mcp.NewToolResultText(result), nil
+			}
+			result += fmt.Sprintf(", running for: %s\n", time.Since(build.Started).Round(time.Second))
+			if req.Collection == "" {
+				req.Collection = build.Collection
+			}
+		}
+
+		ns := "^" + regexp.QuoteMeta(client.DB.Name()+".")
+		if req.Collection != "" {
+			ns += regexp.QuoteMeta(req.Collection) + "$"
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$currentOp", Value: bson.M{"allUsers": true}}},
+			{{Key: "$match", Value: bson.M{
+				"ns": bson.M{"$regex": ns},
+				"$or": bson.A{
+					bson.M{"command.createIndexes": bson.M{"$exists": true}},
+					bson.M{"msg": bson.M{"$regex": "^Index Build"}},
+				},
+			}}},
+		}
+		cur, err := client.MongoClient.Database("admin").Aggregate(ctx, pipeline)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var ops []struct {
+			OpID        interface{} `bson:"opid"`
+			Ns          string      `bson:"ns"`
+			Msg         string      `bson:"msg"`
+			SecsRunning int64       `bson:"secs_running"`
+			Command     struct {
+				Indexes []struct {
+					Name string `bson:"name"`
+				} `bson:"indexes"`
+			} `bson:"command"`
+			Progress *struct {
+				Done  float64 `bson:"done"`
+				Total float64 `bson:"total"`
+			} `bson:"progress"`
+		}
+		if err = cur.All(ctx, &ops); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(ops) == 0 {
+			return mcp.NewToolResultText(result + "No index build in progress"), nil
+		}
+
+		for _, op := range ops {
+			result += fmt.Sprintf("opid: %v, ns: %s, running for: %ds", op.OpID, op.Ns, op.SecsRunning)
+			var names []string
+			for _, index := range op.Command.Indexes {
+				names = append(names, index.Name)
+			}
+			if len(names) > 0 {
+				result += fmt.Sprintf(", indexes: %s", strings.Join(names, ", "))
+			}
+			if op.Msg != "" {
+				result += fmt.Sprintf(", phase: %s", op.Msg)
+			}
+			if op.Progress != nil && op.Progress.Total > 0 {
+				result += fmt.Sprintf(", progress: %.1f%% (%.0f/%.0f)",
+					op.Progress.Done/op.Progress.Total*100, op.Progress.Done, op.Progress.Total)
+			}
+			result += "\n"
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"strings"
+)
+
+// queryShape is the filter and sort of a query to suggest an index for
+type queryShape struct {
+	Filter bson.D `bson:"filter"`
+	Sort   bson.D `bson:"sort"`
+}
+
+// indexCandidate is a proposed index and the queries it serves
+type indexCandidate struct {
+	Keys      bson.D
+	Queries   []queryShape
+	CoveredBy string
+}
+
+// rangeOperators make a field a range predicate, other operators are treated as equality
+var rangeOperators = map[string]bool{
+	"$gt": true, "$gte": true, "$lt": true, "$lte": true, "$ne": true, "$nin": true,
+	"$regex": true, "$exists": true, "$not": true, "$elemMatch": true, "$type": true, "$mod": true,
+}
+
+// filterFields splits the fields of a filter into equality and range predicates
+func filterFields(filter bson.D) (equality []string, ranges []string) {
+	for _, e := range filter {
+		switch {
+		case e.Key == "$and":
+			conditions, _ := e.Value.(bson.A)
+			for _, condition := range conditions {
+				if doc, ok := condition.(bson.D); ok {
+					eq, rg := filterFields(doc)
+					equality = append(equality, eq...)
+					ranges = append(ranges, rg...)
+				}
+			}
+		case strings.HasPrefix(e.Key, "$"):
+			// $or, $expr, $text... can't be served by a single compound index
+		default:
+			isRange := false
+			if doc, ok := e.Value.(bson.D); ok {
+				for _, operator := range doc {
+					if rangeOperators[operator.Key] {
+						isRange = true
+					}
+				}
+			}
+			if isRange {
+				ranges = append(ranges, e.Key)
+			} else {
+				equality = append(equality, e.Key)
+			}
+		}
+	}
+	return
+}
+
+// esrKeys proposes index keys following the equality, sort, range rule
+func esrKeys(query queryShape) bson.D {
+	equality, ranges := filterFields(query.Filter)
+	var keys bson.D
+	seen := map[string]bool{}
+	add := func(field string, direction interface{}) {
+		if !seen[field] {
+			seen[field] = true
+			keys = append(keys, bson.E{Key: field, Value: direction})
+		}
+	}
+	for _, field := range equality {
+		add(field, 1)
+	}
+	for _, e := range query.Sort {
+		add(e.Key, e.Value)
+	}
+	for _, field := range ranges {
+		add(field, 1)
+	}
+	return keys
+}
+
+// profiledQueries returns the shapes of the slowest recent queries recorded by the profiler
+func profiledQueries(ctx context.Context, collection string, minMillis int64, limit int64) ([]queryShape, error) {
+	filter := bson.M{
+		"ns":             client.DB.Name() + "." + collection,
+		"op":             "query",
+		"command.filter": bson.M{"$exists": true},
+		"millis":         bson.M{"$gte": minMillis},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "ts", Value: -1}}).SetLimit(limit)
+	cur, err := client.DB.Collection("system.profile").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var entries []struct {
+		Command queryShape `bson:"command"`
+	}
+	if err = cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	var queries []queryShape
+	for _, entry := range entries {
+		queries = append(queries, entry.Command)
+	}
+	return queries, nil
+}
+
+// explainStats is the part of the executionStats of an explain used to compare plans
+type explainStats struct {
+	NReturned           int64 `bson:"nReturned"`
+	ExecutionTimeMillis int64 `bson:"executionTimeMillis"`
+	TotalKeysExamined   int64 `bson:"totalKeysExamined"`
+	TotalDocsExamined   int64 `bson:"totalDocsExamined"`
+}
+
+func (s explainStats) String() string {
+	return fmt.Sprintf("returned %d, keys examined %d, docs examined %d, %dms",
+		s.NReturned, s.TotalKeysExamined, s.TotalDocsExamined, s.ExecutionTimeMillis)
+}
+
+// explainQuery runs the query with executionStats verbosity, using the hinted index when not empty
+func explainQuery(ctx context.Context, collection string, query queryShape, hint string) (explainStats, error) {
+	find := bson.D{{Key: "find", Value: collection}, {Key: "filter", Value: query.Filter}}
+	if len(query.Sort) > 0 {
+		find = append(find, bson.E{Key: "sort", Value: query.Sort})
+	}
+	if hint != "" {
+		find = append(find, bson.E{Key: "hint", Value: hint})
+	}
+	command := bson.D{{Key: "explain", Value: find}, {Key: "verbosity", Value: "executionStats"}}
+
+	var result struct {
+		ExecutionStats explainStats `bson:"executionStats"`
+	}
+	err := client.DB.RunCommand(ctx, command).Decode(&result)
+	return result.ExecutionStats, err
+}
+
+// estimateBenefit builds the candidate as a hidden index, explains its queries with and without it, then drops it
+func estimateBenefit(ctx context.Context, collection string, candidate indexCandidate) (string, error) {
+	coll := client.DB.Collection(collection)
+	// the build may take a while on large collections, hidden indexes are ignored by the planner meanwhile
+	name, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    candidate.Keys,
+		Options: options.Index().SetName("mcp_candidate_" + indexName(candidate.Keys)).SetHidden(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if _, err := coll.Indexes().DropOne(context.Background(), name); err != nil {
+			log.Printf("Drop candidate index %s failed: %v", name, err)
+		}
+	}()
+
+	// the current plans are explained while the candidate is still hidden from the planner
+	befores := make([]explainStats, len(candidate.Queries))
+	for i, query := range candidate.Queries {
+		if befores[i], err = explainQuery(ctx, collection, query, ""); err != nil {
+			return "", err
+		}
+	}
+
+	// hidden indexes can't be hinted, unhide it only for the time of the hinted explains
+	if err = setIndexHidden(ctx, collection, name, false); err != nil {
+		return "", err
+	}
+	afters, err := explainHinted(ctx, collection, candidate.Queries, name)
+	if hideErr := setIndexHidden(context.Background(), collection, name, true); hideErr != nil {
+		log.Printf("Hide candidate index %s failed: %v", name, hideErr)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	for i, query := range candidate.Queries {
+		filter, _ := bson.MarshalExtJSON(query.Filter, false, false)
+		result += fmt.Sprintf("    query %s\n      current plan: %s\n      with index:   %s\n", filter, befores[i], afters[i])
+	}
+	return result, nil
+}
+
+// explainHinted explains each query with the hinted index
+func explainHinted(ctx context.Context, collection string, queries []queryShape, hint string) ([]explainStats, error) {
+	stats := make([]explainStats, len(queries))
+	for i, query := range queries {
+		var err error
+		if stats[i], err = explainQuery(ctx, collection, query, hint); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// SuggestIndexes propose indexes for query shapes
+func (c indexTool) SuggestIndexes() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"SuggestIndexes",
+		mcp.WithDescription("propose indexes for sample queries or the recent slow queries of the profiler, "+
+			"following the equality, sort, range rule, and compare them against the existing indexes"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("queries",
+			mcp.Description("Sample queries as an Extended JSON array of {filter, sort} documents "+
+				"(e.g., [{\"filter\": {\"status\": \"open\", \"total\": {\"$gt\": 100}}, \"sort\": {\"createdAt\": -1}}])"),
+		),
+		mcp.WithBoolean("from_profiler",
+			mcp.Description("Use the recent queries recorded by the database profiler (system.profile)"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("profiler_limit",
+			mcp.Description("Number of recent profiled queries to analyze"),
+			mcp.DefaultNumber(20),
+			mcp.Min(1),
+			mcp.Max(500),
+		),
+		mcp.WithNumber("min_millis",
+			mcp.Description("Only analyze profiled queries slower than this"),
+			mcp.DefaultNumber(100),
+			mcp.Min(0),
+		),
+		mcp.WithBoolean("estimate",
+			mcp.Description("Estimate the benefit by building each new candidate as a temporary hidden index "+
+				"and explaining the queries with and without it. Builds indexes, avoid on busy large collections"),
+			mcp.DefaultBool(false),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.SuggestIndexesRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if req.ProfilerLimit <= 0 {
+			req.ProfilerLimit = 20
+		}
+
+		var queries []queryShape
+		if req.Queries != "" {
+			docs, err := parseDocuments(req.Queries)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("parse queries failed: %v", err)), nil
+			}
+			for _, doc := range docs {
+				var query queryShape
+				data, _ := bson.Marshal(doc)
+				if err = bson.Unmarshal(data, &query); err != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("parse queries failed: %v", err)), nil
+				}
+				queries = append(queries, query)
+			}
+		}
+		if req.FromProfiler {
+			profiled, err := profiledQueries(ctx, req.Collection, req.MinMillis, req.ProfilerLimit)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			queries = append(queries, profiled...)
+		}
+		if len(queries) == 0 {
+			return mcp.NewToolResultText("No queries to analyze, pass queries or enable from_profiler with profiling level 1 or 2"), nil
+		}
+
+		cur, err := client.DB.Collection(req.Collection).Indexes().List(ctx)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+		var indexes []struct {
+			Name string `bson:"name"`
+			Key  bson.D `bson:"key"`
+		}
+		if err = cur.All(ctx, &indexes); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		// group the queries by proposed index
+		var candidates []*indexCandidate
+		byKeys := map[string]*indexCandidate{}
+		for _, query := range queries {
+			keys := esrKeys(query)
+			if len(keys) == 0 {
+				continue
+			}
+			name := indexName(keys)
+			candidate, ok := byKeys[name]
+			if !ok {
+				candidate = &indexCandidate{Keys: keys}
+				for _, index := range indexes {
+					if keyPrefix(keys, index.Key) {
+						candidate.CoveredBy = index.Name
+						break
+					}
+				}
+				byKeys[name] = candidate
+				candidates = append(candidates, candidate)
+			}
+			candidate.Queries = append(candidate.Queries, query)
+		}
+		if len(candidates) == 0 {
+			return mcp.NewToolResultText("No indexable predicates found in the queries"), nil
+		}
+
+		var result string
+		for _, candidate := range candidates {
+			keys, _ := bson.MarshalExtJSON(candidate.Keys, false, false)
+			if candidate.CoveredBy != "" {
+				result += fmt.Sprintf("%s: already served by index %s (%d queries)\n", keys, candidate.CoveredBy, len(candidate.Queries))
+				continue
+			}
+			result += fmt.Sprintf("%s: new index suggested (%d queries)\n", keys, len(candidate.Queries))
+			if req.Estimate {
+				estimate, err := estimateBenefit(ctx, req.Collection, *candidate)
+				if err != nil {
+					result += fmt.Sprintf("    estimate failed: %v\n", err)
+					continue
+				}
+				result += estimate
+			}
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEsrKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		sort   bson.D
+		want   bson.D
+	}{
+		{
+			name:   "equality only",
+			filter: `{"status": "open", "owner": "ann"}`,
+			want:   bson.D{{Key: "status", Value: 1}, {Key: "owner", Value: 1}},
+		},
+		{
+			name:   "equality, sort, range",
+			filter: `{"total": {"$gt": 100}, "status": "open"}`,
+			sort:   bson.D{{Key: "createdAt", Value: -1}},
+			want:   bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "total", Value: 1}},
+		},
+		{
+			name:   "equality operators",
+			filter: `{"status": {"$in": ["open", "closed"]}, "owner": {"$eq": "ann"}}`,
+			want:   bson.D{{Key: "status", Value: 1}, {Key: "owner", Value: 1}},
+		},
+		{
+			name:   "$and is flattened",
+			filter: `{"$and": [{"status": "open"}, {"total": {"$lte": 5}}]}`,
+			want:   bson.D{{Key: "status", Value: 1}, {Key: "total", Value: 1}},
+		},
+		{
+			name:   "$or is skipped",
+			filter: `{"$or": [{"a": 1}, {"b": 2}], "status": "open"}`,
+			want:   bson.D{{Key: "status", Value: 1}},
+		},
+		{
+			name:   "sorted equality field is not repeated",
+			filter: `{"status": "open"}`,
+			sort:   bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+			want:   bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseDocument(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := esrKeys(queryShape{Filter: filter, Sort: tt.sort})
+			if indexName(got) != indexName(tt.want) {
+				t.Errorf("esrKeys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterFields(t *testing.T) {
+	filter, err := parseDocument(`{"a": 1, "b": {"$exists": true}, "c": {"$regex": "^x"}, "d": {"$in": [1]}, "$expr": {}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equality, ranges := filterFields(filter)
+	if !reflect.DeepEqual(equality, []string{"a", "d"}) {
+		t.Errorf("equality = %v, want [a d]", equality)
+	}
+	if !reflect.DeepEqual(ranges, []string{"b", "c"}) {
+		t.Errorf("ranges = %v, want [b c]", ranges)
+	}
+}
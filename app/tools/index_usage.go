@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mcp/app/client"
+	"mcp/app/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexStats is a document returned by $indexStats
+type indexStats struct {
+	Name     string `bson:"name"`
+	Key      bson.D `bson:"key"`
+	Accesses struct {
+		Ops   int64     `bson:"ops"`
+		Since time.Time `bson:"since"`
+	} `bson:"accesses"`
+	Spec bson.D `bson:"spec"`
+}
+
+// constrains tells if the index enforces or limits something besides speeding up queries
+func (s indexStats) constrains() bool {
+	for _, option := range []string{"unique", "partialFilterExpression", "sparse", "expireAfterSeconds", "collation"} {
+		if value, ok := lookup(s.Spec, option); ok && value != false {
+			return true
+		}
+	}
+	return false
+}
+
+// covers tells if the index serves every query s serves: it indexes all documents, is visible to the planner
+// and compares strings the same way
+func (s indexStats) covers(other indexStats) bool {
+	for _, option := range []string{"partialFilterExpression", "sparse", "hidden"} {
+		if value, ok := lookup(s.Spec, option); ok && value != false {
+			return false
+		}
+	}
+	collation, _ := lookup(s.Spec, "collation")
+	otherCollation, _ := lookup(other.Spec, "collation")
+	return fmt.Sprint(collation) == fmt.Sprint(otherCollation)
+}
+
+// keyPrefix tells if the keys of a are equal to the first keys of b
+func keyPrefix(a bson.D, b bson.D) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || fmt.Sprint(a[i].Value) != fmt.Sprint(b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexSizes returns the size in bytes of each index of the collection
+func indexSizes(ctx context.Context, collection string) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{{{Key: "$collStats", Value: bson.M{"storageStats": bson.M{}}}}}
+	cur, err := client.DB.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var stats []struct {
+		StorageStats struct {
+			IndexSizes map[string]int64 `bson:"indexSizes"`
+		} `bson:"storageStats"`
+	}
+	if err = cur.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	// sharded collections return one document per shard
+	sizes := map[string]int64{}
+	for _, stat := range stats {
+		for name, size := range stat.StorageStats.IndexSizes {
+			sizes[name] += size
+		}
+	}
+	return sizes, nil
+}
+
+// IndexUsage report index usage statistics and cleanup candidates
+func (c indexTool) IndexUsage() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"IndexUsage",
+		mcp.WithDescription("report how often each index was used since the server started, and flag unused, "+
+			"redundant (prefix of another index) and duplicate indexes with their sizes. An index is only redundant or duplicate "+
+			"next to a non-partial, non-sparse, visible index with the same collation. Statistics are per server, "+
+			"check every member before dropping an index"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.IndexUsageRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		cur, err := client.DB.Collection(req.Collection).Aggregate(ctx, mongo.Pipeline{{{Key: "$indexStats", Value: bson.M{}}}})
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var stats []indexStats
+		if err = cur.All(ctx, &stats); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(stats) == 0 {
+			return mcp.NewToolResultText("No indexes found"), nil
+		}
+		sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+		sizes, err := indexSizes(ctx, req.Collection)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		var result string
+		var reclaimable int64
+		for _, index := range stats {
+			var flags []string
+			if index.Name != "_id_" {
+				if index.Accesses.Ops == 0 {
+					flags = append(flags, "unused")
+				}
+				for _, other := range stats {
+					if other.Name == index.Name || !other.covers(index) {
+						continue
+					}
+					switch {
+					case len(other.Key) == len(index.Key) && keyPrefix(index.Key, other.Key):
+						// only flag one index of a duplicate pair, the one the other covers
+						if !index.covers(other) || other.Name < index.Name {
+							flags = append(flags, "duplicate of "+other.Name)
+						}
+					case !index.constrains() && keyPrefix(index.Key, other.Key):
+						flags = append(flags, "redundant, prefix of "+other.Name)
+					}
+				}
+			}
+
+			key, err := bson.MarshalExtJSON(index.Key, false, false)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			result += fmt.Sprintf("name: %s, key: %s, accesses: %d, since: %s, size: %d bytes",
+				index.Name, key, index.Accesses.Ops, index.Accesses.Since.Format(time.RFC3339), sizes[index.Name])
+			if len(flags) > 0 {
+				result += ", flags: " + strings.Join(flags, "; ")
+				reclaimable += sizes[index.Name]
+			}
+			result += "\n"
+		}
+		if reclaimable > 0 {
+			result += fmt.Sprintf("Flagged indexes use %d bytes, hide them with HideIndex to check the impact before dropping", reclaimable)
+		} else {
+			result += "No cleanup candidates found"
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		a    bson.D
+		b    bson.D
+		want bool
+	}{
+		{"equal", bson.D{{Key: "a", Value: 1}}, bson.D{{Key: "a", Value: 1}}, true},
+		{"prefix", bson.D{{Key: "a", Value: 1}}, bson.D{{Key: "a", Value: 1}, {Key: "b", Value: -1}}, true},
+		{"number types", bson.D{{Key: "a", Value: int32(1)}}, bson.D{{Key: "a", Value: 1.0}}, true},
+		{"longer", bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 1}}, bson.D{{Key: "a", Value: 1}}, false},
+		{"other direction", bson.D{{Key: "a", Value: 1}}, bson.D{{Key: "a", Value: -1}, {Key: "b", Value: 1}}, false},
+		{"other order", bson.D{{Key: "b", Value: 1}}, bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 1}}, false},
+		{"text index", bson.D{{Key: "a", Value: "text"}}, bson.D{{Key: "a", Value: 1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyPrefix(tt.a, tt.b); got != tt.want {
+				t.Errorf("keyPrefix = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexStatsCovers(t *testing.T) {
+	french := bson.D{{Key: "locale", Value: "fr"}}
+	tests := []struct {
+		name  string
+		index bson.D
+		other bson.D
+		want  bool
+	}{
+		{"plain", bson.D{}, bson.D{}, true},
+		{"other constrains", bson.D{}, bson.D{{Key: "unique", Value: true}}, true},
+		{"partial", bson.D{{Key: "partialFilterExpression", Value: bson.D{{Key: "a", Value: 1}}}}, bson.D{}, false},
+		{"sparse", bson.D{{Key: "sparse", Value: true}}, bson.D{}, false},
+		{"not sparse", bson.D{{Key: "sparse", Value: false}}, bson.D{}, true},
+		{"hidden", bson.D{{Key: "hidden", Value: true}}, bson.D{}, false},
+		{"same collation", bson.D{{Key: "collation", Value: french}}, bson.D{{Key: "collation", Value: french}}, true},
+		{"other collation", bson.D{{Key: "collation", Value: french}}, bson.D{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := indexStats{Spec: tt.index}
+			other := indexStats{Spec: tt.other}
+			if got := index.covers(other); got != tt.want {
+				t.Errorf("covers = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexStatsConstrains(t *testing.T) {
+	tests := []struct {
+		spec bson.D
+		want bool
+	}{
+		{bson.D{{Key: "name", Value: "a_1"}}, false},
+		{bson.D{{Key: "unique", Value: true}}, true},
+		{bson.D{{Key: "unique", Value: false}}, false},
+		{bson.D{{Key: "expireAfterSeconds", Value: 3600}}, true},
+		{bson.D{{Key: "sparse", Value: true}}, true},
+	}
+	for _, tt := range tests {
+		if got := (indexStats{Spec: tt.spec}).constrains(); got != tt.want {
+			t.Errorf("constrains(%v) = %t, want %t", tt.spec, got, tt.want)
+		}
+	}
+}
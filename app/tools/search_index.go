@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mcp/app/client"
+	"mcp/app/model"
+)
+
+const (
+	searchIndexTypeSearch       = "search"
+	searchIndexTypeVectorSearch = "vectorSearch"
+)
+
+type SearchIndexTool interface {
+	// ListSearchIndexes get Atlas Search and Vector Search indexes of a collection
+	ListSearchIndexes() (mcp.Tool, server.ToolHandlerFunc)
+	// CreateSearchIndex create a search or vector search index
+	CreateSearchIndex() (mcp.Tool, server.ToolHandlerFunc)
+	// UpdateSearchIndex replace the definition of a search index
+	UpdateSearchIndex() (mcp.Tool, server.ToolHandlerFunc)
+	// DropSearchIndex drop a search index
+	DropSearchIndex() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type searchIndexTool struct{}
+
+func NewSearchIndexTool() SearchIndexTool {
+	return &searchIndexTool{}
+}
+
+// searchIndexDefinition returns the definition of the request, built from the vector
+// fields when no definition is given for a vectorSearch index
+func searchIndexDefinition(req model.SearchIndexRequest) (bson.D, error) {
+	if req.Definition != "" {
+		return parseDocument(req.Definition)
+	}
+	if req.Type != searchIndexTypeVectorSearch {
+		return nil, fmt.Errorf("definition is required for %s indexes", searchIndexTypeSearch)
+	}
+	if req.Path == "" || req.NumDimensions <= 0 {
+		return nil, fmt.Errorf("path and num_dimensions are required to build a vectorSearch definition")
+	}
+	similarity := req.Similarity
+	if similarity == "" {
+		similarity = "cosine"
+	}
+	fields := bson.A{bson.D{
+		{Key: "type", Value: "vector"},
+		{Key: "path", Value: req.Path},
+		{Key: "numDimensions", Value: req.NumDimensions},
+		{Key: "similarity", Value: similarity},
+	}}
+	for _, field := range req.FilterFields {
+		fields = append(fields, bson.D{{Key: "type", Value: "filter"}, {Key: "path", Value: field}})
+	}
+	return bson.D{{Key: "fields", Value: fields}}, nil
+}
+
+// searchIndexParams are the tool parameters describing a search index definition
+func searchIndexParams(action string) []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Name of the search index to %s", action)),
+		),
+		mcp.WithString("type",
+			mcp.Description("Index type"),
+			mcp.Enum(searchIndexTypeSearch, searchIndexTypeVectorSearch),
+			mcp.DefaultString(searchIndexTypeSearch),
+		),
+		mcp.WithString("definition",
+			mcp.Description("Index definition as Extended JSON (e.g., {\"mappings\": {\"dynamic\": true}}), "+
+				"optional for vectorSearch indexes described by path and num_dimensions"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Field holding the embeddings of a vectorSearch index"),
+		),
+		mcp.WithNumber("num_dimensions",
+			mcp.Description("Number of dimensions of the embeddings of a vectorSearch index"),
+			mcp.Min(1),
+			mcp.Max(8192),
+		),
+		mcp.WithString("similarity",
+			mcp.Description("Similarity function of a vectorSearch index"),
+			mcp.Enum("cosine", "euclidean", "dotProduct"),
+			mcp.DefaultString("cosine"),
+		),
+		mcp.WithArray("filter_fields",
+			mcp.Description("Fields of a vectorSearch index usable in the pre-filter"),
+			mcp.Items(map[string]interface{}{"type": "string"}),
+		),
+	}
+}
+
+// ListSearchIndexes get Atlas Search and Vector Search indexes of a collection
+func (s searchIndexTool) ListSearchIndexes() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ListSearchIndexes",
+		mcp.WithDescription("List the Atlas Search and Vector Search indexes of a collection with their status"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Only list the search index with this name"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ListSearchIndexesRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		opts := options.SearchIndexes()
+		if req.Name != "" {
+			opts.SetName(req.Name)
+		}
+		cur, err := client.DB.Collection(req.Collection).SearchIndexes().List(ctx, opts)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var indexes []struct {
+			Name             string `bson:"name"`
+			Type             string `bson:"type"`
+			Status           string `bson:"status"`
+			Queryable        bool   `bson:"queryable"`
+			LatestDefinition bson.D `bson:"latestDefinition"`
+		}
+		if err = cur.All(ctx, &indexes); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(indexes) == 0 {
+			return mcp.NewToolResultText("No search indexes found"), nil
+		}
+
+		var result string
+		for _, index := range indexes {
+			definition, err := bson.MarshalExtJSON(index.LatestDefinition, false, false)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			result += fmt.Sprintf("name: %s, type: %s, status: %s, queryable: %t, definition: %s\n",
+				index.Name, index.Type, index.Status, index.Queryable, definition)
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	return
+}
+
+// CreateSearchIndex create a search or vector search index
+func (s searchIndexTool) CreateSearchIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CreateSearchIndex",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create an Atlas Search or Vector Search index, the index builds asynchronously, " +
+				"check its status with ListSearchIndexes"),
+		}, searchIndexParams("create")...)...,
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.SearchIndexRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if req.Type == "" {
+			req.Type = searchIndexTypeSearch
+		}
+
+		definition, err := searchIndexDefinition(req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		name, err := client.DB.Collection(req.Collection).SearchIndexes().CreateOne(ctx, mongo.SearchIndexModel{
+			Definition: definition,
+			Options:    options.SearchIndexes().SetName(req.Name).SetType(req.Type),
+		})
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Search index %s created, it is queryable once its build completes", name)), nil
+	}
+	return
+}
+
+// UpdateSearchIndex replace the definition of a search index
+func (s searchIndexTool) UpdateSearchIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"UpdateSearchIndex",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Replace the definition of an Atlas Search or Vector Search index, " +
+				"the previous definition serves queries until the new one is built"),
+		}, searchIndexParams("update")...)...,
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.SearchIndexRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if req.Type == "" {
+			req.Type = searchIndexTypeSearch
+		}
+
+		definition, err := searchIndexDefinition(req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if err = client.DB.Collection(req.Collection).SearchIndexes().UpdateOne(ctx, req.Name, definition); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Search index %s updated", req.Name)), nil
+	}
+	return
+}
+
+// DropSearchIndex drop a search index
+func (s searchIndexTool) DropSearchIndex() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"DropSearchIndex",
+		mcp.WithDescription("Drop an Atlas Search or Vector Search index"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the search index to drop"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.DropSearchIndexRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		if err = client.DB.Collection(req.Collection).SearchIndexes().DropOne(ctx, req.Name); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Search index %s dropped", req.Name)), nil
+	}
+	return
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"mcp/app/model"
+)
+
+func TestSearchIndexDefinition(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     model.SearchIndexRequest
+		want    string
+		wantErr string
+	}{
+		{
+			name: "explicit definition",
+			req:  model.SearchIndexRequest{Type: searchIndexTypeSearch, Definition: `{"mappings": {"dynamic": true}}`},
+			want: `{"mappings":{"dynamic":true}}`,
+		},
+		{
+			name: "explicit definition wins over vector fields",
+			req: model.SearchIndexRequest{Type: searchIndexTypeVectorSearch, Definition: `{"fields": []}`,
+				Path: "embedding", NumDimensions: 3},
+			want: `{"fields":[]}`,
+		},
+		{
+			name: "vector fields with default similarity",
+			req:  model.SearchIndexRequest{Type: searchIndexTypeVectorSearch, Path: "embedding", NumDimensions: 1536},
+			want: `{"fields":[{"type":"vector","path":"embedding","numDimensions":1536,"similarity":"cosine"}]}`,
+		},
+		{
+			name: "vector fields with filters",
+			req: model.SearchIndexRequest{Type: searchIndexTypeVectorSearch, Path: "embedding", NumDimensions: 3,
+				Similarity: "dotProduct", FilterFields: []string{"category", "year"}},
+			want: `{"fields":[{"type":"vector","path":"embedding","numDimensions":3,"similarity":"dotProduct"},` +
+				`{"type":"filter","path":"category"},{"type":"filter","path":"year"}]}`,
+		},
+		{
+			name:    "search without definition",
+			req:     model.SearchIndexRequest{Type: searchIndexTypeSearch},
+			wantErr: "definition is required",
+		},
+		{
+			name:    "vector without path",
+			req:     model.SearchIndexRequest{Type: searchIndexTypeVectorSearch, NumDimensions: 3},
+			wantErr: "path and num_dimensions are required",
+		},
+		{
+			name:    "vector without dimensions",
+			req:     model.SearchIndexRequest{Type: searchIndexTypeVectorSearch, Path: "embedding"},
+			wantErr: "path and num_dimensions are required",
+		},
+		{
+			name:    "invalid definition",
+			req:     model.SearchIndexRequest{Type: searchIndexTypeSearch, Definition: `{"mappings":`},
+			wantErr: "parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, err := searchIndexDefinition(tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := bson.MarshalExtJSON(definition, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("definition = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchIndexTools(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tool := NewSearchIndexTool()
+
+	mt.Run("create validates before calling the server", func(mt *mtest.T) {
+		mockDB(mt)
+		_, handler := tool.CreateSearchIndex()
+		text, err := callTool(t, handler, map[string]interface{}{"collection": "movies", "name": "default"})
+		if err != nil || !strings.Contains(text, "definition is required") {
+			t.Errorf("result = %q, %v", text, err)
+		}
+		if started := mt.GetAllStartedEvents(); len(started) != 0 {
+			t.Errorf("%d commands sent, want none", len(started))
+		}
+	})
+
+	mt.Run("create vectorSearch", func(mt *mtest.T) {
+		mockDB(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "indexesCreated", Value: bson.A{
+			bson.D{{Key: "id", Value: "1"}, {Key: "name", Value: "vectors"}},
+		}}))
+		_, handler := tool.CreateSearchIndex()
+		text, err := callTool(t, handler, map[string]interface{}{
+			"collection": "movies", "name": "vectors", "type": "vectorSearch", "path": "embedding", "num_dimensions": 3,
+		})
+		if err != nil || !strings.Contains(text, "Search index vectors created") {
+			t.Fatalf("result = %q, %v", text, err)
+		}
+		command := mt.GetStartedEvent().Command
+		index := command.Lookup("indexes", "0")
+		if got := index.Document().Lookup("type").StringValue(); got != "vectorSearch" {
+			t.Errorf("type = %s, want vectorSearch", got)
+		}
+		if got := index.Document().Lookup("definition", "fields", "0", "numDimensions").Int32(); got != 3 {
+			t.Errorf("numDimensions = %d, want 3", got)
+		}
+	})
+
+	mt.Run("list reports status and queryable", func(mt *mtest.T) {
+		mockDB(mt)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.movies", mtest.FirstBatch,
+			bson.D{
+				{Key: "name", Value: "default"},
+				{Key: "type", Value: "search"},
+				{Key: "status", Value: "READY"},
+				{Key: "queryable", Value: true},
+				{Key: "latestDefinition", Value: bson.D{{Key: "mappings", Value: bson.D{{Key: "dynamic", Value: true}}}}},
+			},
+		))
+		_, handler := tool.ListSearchIndexes()
+		text, err := callTool(t, handler, map[string]interface{}{"collection": "movies"})
+		want := `name: default, type: search, status: READY, queryable: true, definition: {"mappings":{"dynamic":true}}`
+		if err != nil || !strings.Contains(text, want) {
+			t.Errorf("result = %q, %v", text, err)
+		}
+	})
+}
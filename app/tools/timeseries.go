@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"slices"
+	"time"
+)
+
+// timeSeriesAggregations are the accumulators TimeSeriesQuery applies to the fields of a bucket
+var timeSeriesAggregations = []string{"avg", "min", "max", "sum", "first", "last", "stdDevPop"}
+
+type TimeSeriesTool interface {
+	// CreateTimeSeries create a time-series collection
+	CreateTimeSeries() (mcp.Tool, server.ToolHandlerFunc)
+	// TimeSeriesQuery get measurements aggregated by time interval
+	TimeSeriesQuery() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type timeSeriesTool struct{}
+
+func NewTimeSeriesTool() TimeSeriesTool {
+	return &timeSeriesTool{}
+}
+
+// CreateTimeSeries create a time-series collection
+func (t timeSeriesTool) CreateTimeSeries() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CreateTimeSeries",
+		mcp.WithDescription("Create a time-series collection, storing measurements in buckets by time and meta field"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("time_field",
+			mcp.Required(),
+			mcp.Description("Field holding the date of each measurement"),
+		),
+		mcp.WithString("meta_field",
+			mcp.Description("Field identifying the series (e.g., the sensor id), which rarely changes"),
+		),
+		mcp.WithString("granularity",
+			mcp.Description("Expected interval between measurements of a series, exclusive with the bucket settings"),
+			mcp.Enum("seconds", "minutes", "hours"),
+		),
+		mcp.WithNumber("bucket_max_span_seconds",
+			mcp.Description("Maximum time span of a bucket, set together with bucket_rounding_seconds to the same value"),
+			mcp.Min(1),
+			mcp.Max(31536000),
+		),
+		mcp.WithNumber("bucket_rounding_seconds",
+			mcp.Description("Interval the start of a bucket is rounded down to"),
+			mcp.Min(1),
+			mcp.Max(31536000),
+		),
+		mcp.WithNumber("expire_after_seconds",
+			mcp.Description("Delete measurements older than this"),
+			mcp.Min(1),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.CreateTimeSeriesRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		if req.BucketMaxSpanSeconds != req.BucketRoundingSeconds {
+			return mcp.NewToolResultText("bucket_max_span_seconds and bucket_rounding_seconds must be set together to the same value"), nil
+		}
+		if req.Granularity != "" && req.BucketMaxSpanSeconds > 0 {
+			return mcp.NewToolResultText("granularity can't be combined with the bucket settings"), nil
+		}
+
+		timeSeries := options.TimeSeries().SetTimeField(req.TimeField)
+		if req.MetaField != "" {
+			timeSeries.SetMetaField(req.MetaField)
+		}
+		if req.Granularity != "" {
+			timeSeries.SetGranularity(req.Granularity)
+		}
+		if req.BucketMaxSpanSeconds > 0 {
+			timeSeries.SetBucketMaxSpan(time.Duration(req.BucketMaxSpanSeconds) * time.Second)
+			timeSeries.SetBucketRounding(time.Duration(req.BucketRoundingSeconds) * time.Second)
+		}
+		opts := options.CreateCollection().SetTimeSeriesOptions(timeSeries)
+		if req.ExpireAfterSeconds != nil {
+			opts.SetExpireAfterSeconds(*req.ExpireAfterSeconds)
+		}
+
+		log.Printf("Create time-series collection %s, time field: %s, meta field: %s", req.Name, req.TimeField, req.MetaField)
+
+		if err = client.DB.CreateCollection(ctx, req.Name, opts); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Time-series collection %s created", req.Name)), nil
+	}
+	return
+}
+
+// TimeSeriesQuery get measurements aggregated by time interval
+func (t timeSeriesTool) TimeSeriesQuery() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"TimeSeriesQuery",
+		mcp.WithDescription("Aggregate the measurements of a time range into one point per interval, "+
+			"optionally per series and with a moving average, instead of reading raw measurements"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("start",
+			mcp.Required(),
+			mcp.Description("Start of the time range, inclusive, RFC 3339 (e.g., 2024-05-01T00:00:00Z)"),
+		),
+		mcp.WithString("end",
+			mcp.Description("End of the time range, exclusive, RFC 3339, defaults to now"),
+		),
+		mcp.WithString("unit",
+			mcp.Required(),
+			mcp.Description("Unit of the interval"),
+			mcp.Enum("second", "minute", "hour", "day", "week", "month", "quarter", "year"),
+		),
+		mcp.WithNumber("bin_size",
+			mcp.Description("Number of units in an interval (e.g., 15 with unit minute)"),
+			mcp.DefaultNumber(1),
+			mcp.Min(1),
+		),
+		mcp.WithArray("fields",
+			mcp.Required(),
+			mcp.Description("Measurement fields to aggregate"),
+			mcp.Items(map[string]interface{}{"type": "string"}),
+		),
+		mcp.WithArray("aggregations",
+			mcp.Description("Accumulators applied to each field, output as <field>_<aggregation>, defaults to avg"),
+			mcp.Items(map[string]interface{}{"type": "string", "enum": timeSeriesAggregations}),
+		),
+		mcp.WithString("group_by",
+			mcp.Description("Field splitting the points per series, usually the meta field or one of its subfields"),
+		),
+		mcp.WithString("time_field",
+			mcp.Description("Field holding the date, defaults to the timeField of a time-series collection"),
+		),
+		mcp.WithString("timezone",
+			mcp.Description("Timezone the intervals are aligned on (e.g., Europe/Paris), defaults to UTC"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("MongoDB query filter on the measurements"),
+		),
+		mcp.WithNumber("moving_window",
+			mcp.Description("Add a <field>_<aggregation>_moving average over this number of consecutive points of a series"),
+			mcp.Min(2),
+			mcp.Max(1000),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of points to return"),
+			mcp.DefaultNumber(1000),
+			mcp.Min(1),
+			mcp.Max(10000),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.TimeSeriesQueryRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.BinSize <= 0 {
+			req.BinSize = 1
+		}
+		if req.Limit <= 0 {
+			req.Limit = 1000
+		}
+		if req.Timezone == "" {
+			req.Timezone = "UTC"
+		}
+		if len(req.Aggregations) == 0 {
+			req.Aggregations = []string{"avg"}
+		}
+		if len(req.Fields) == 0 {
+			return mcp.NewToolResultText("fields is required"), nil
+		}
+		for _, aggregation := range req.Aggregations {
+			if !slices.Contains(timeSeriesAggregations, aggregation) {
+				return mcp.NewToolResultText(fmt.Sprintf("unknown aggregation %s, use one of %v", aggregation, timeSeriesAggregations)), nil
+			}
+		}
+
+		start, err := time.Parse(time.RFC3339, req.Start)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("invalid start: %v", err)), nil
+		}
+		end := time.Now()
+		if req.End != "" {
+			if end, err = time.Parse(time.RFC3339, req.End); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("invalid end: %v", err)), nil
+			}
+		}
+		if req.TimeField == "" {
+			info, err := getCollectionInfo(ctx, req.Collection)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), nil
+			}
+			if info.Options.TimeSeries.TimeField == "" {
+				return mcp.NewToolResultText(fmt.Sprintf("%s is not a time-series collection, pass time_field", req.Collection)), nil
+			}
+			req.TimeField = info.Options.TimeSeries.TimeField
+		}
+
+		match := bson.D{{Key: req.TimeField, Value: bson.D{{Key: "$gte", Value: start}, {Key: "$lt", Value: end}}}}
+		for key, value := range req.Filter {
+			match = append(match, bson.E{Key: key, Value: value})
+		}
+		id := bson.D{{Key: "time", Value: bson.D{{Key: "$dateTrunc", Value: bson.D{
+			{Key: "date", Value: "$" + req.TimeField},
+			{Key: "unit", Value: req.Unit},
+			{Key: "binSize", Value: req.BinSize},
+			{Key: "timezone", Value: req.Timezone},
+		}}}}}
+		if req.GroupBy != "" {
+			id = append(id, bson.E{Key: "series", Value: "$" + req.GroupBy})
+		}
+		group := bson.D{{Key: "_id", Value: id}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}
+		var outputs []string
+		for _, field := range req.Fields {
+			for _, aggregation := range req.Aggregations {
+				output := field + "_" + aggregation
+				outputs = append(outputs, output)
+				group = append(group, bson.E{Key: output, Value: bson.D{{Key: "$" + aggregation, Value: "$" + field}}})
+			}
+		}
+
+		pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+		// $first and $last depend on the order of the measurements
+		pipeline = append(pipeline,
+			bson.D{{Key: "$sort", Value: bson.D{{Key: req.TimeField, Value: 1}}}},
+			bson.D{{Key: "$group", Value: group}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "time", Value: "$_id.time"}, {Key: "series", Value: "$_id.series"}}}},
+			bson.D{{Key: "$unset", Value: "_id"}},
+		)
+		if req.MovingWindow > 1 {
+			window := bson.D{}
+			for _, output := range outputs {
+				window = append(window, bson.E{Key: output + "_moving", Value: bson.D{
+					{Key: "$avg", Value: "$" + output},
+					{Key: "window", Value: bson.D{{Key: "documents", Value: bson.A{-(req.MovingWindow - 1), 0}}}},
+				}})
+			}
+			setWindowFields := bson.D{{Key: "sortBy", Value: bson.D{{Key: "time", Value: 1}}}, {Key: "output", Value: window}}
+			if req.GroupBy != "" {
+				setWindowFields = append(bson.D{{Key: "partitionBy", Value: "$series"}}, setWindowFields...)
+			}
+			pipeline = append(pipeline, bson.D{{Key: "$setWindowFields", Value: setWindowFields}})
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "series", Value: 1}, {Key: "time", Value: 1}}}},
+			bson.D{{Key: "$limit", Value: req.Limit + 1}},
+		)
+
+		log.Printf("Time-series query on %s from %s to %s by %d %s", req.Collection, start, end, req.BinSize, req.Unit)
+
+		cur, err := client.DB.Collection(req.Collection).Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var points []bson.D
+		if err = cur.All(ctx, &points); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(points) == 0 {
+			return mcp.NewToolResultText("No measurements found in the time range"), nil
+		}
+		truncated := int64(len(points)) > req.Limit
+		if truncated {
+			points = points[:req.Limit]
+		}
+		result, err := formatDocuments(points)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		header := fmt.Sprintf("%d points", len(points))
+		if truncated {
+			header += ", truncated, narrow the time range or increase the interval"
+		}
+		return mcp.NewToolResultText(header + "\n" + result), nil
+	}
+	return
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"mcp/app/client"
+)
+
+// mockDB points the tools at the database of a mock deployment for the duration of a test
+func mockDB(mt *mtest.T) {
+	db := client.DB
+	client.DB = mt.DB
+	mt.Cleanup(func() { client.DB = db })
+}
+
+// callTool calls a tool handler with arguments and returns the text of its result
+func callTool(t *testing.T, handler server.ToolHandlerFunc, arguments map[string]interface{}) (string, error) {
+	t.Helper()
+	var request mcp.CallToolRequest
+	request.Params.Arguments = arguments
+	result, err := handler(context.Background(), request)
+	if result == nil || len(result.Content) == 0 {
+		t.Fatalf("tool returned no content, error: %v", err)
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("tool returned %T, want text", result.Content[0])
+	}
+	return text.Text, err
+}
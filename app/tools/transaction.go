@@ -0,0 +1,263 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"sync"
+	"time"
+)
+
+// maxTransactionTimeout is the default transactionLifetimeLimitSeconds of the server, which aborts longer transactions itself
+const (
+	defaultTransactionTimeout = 60 * time.Second
+	maxTransactionTimeout     = 60 * time.Second
+)
+
+type TransactionTool interface {
+	// StartTransaction start a multi-document transaction
+	StartTransaction() (mcp.Tool, server.ToolHandlerFunc)
+	// CommitTransaction commit a started transaction
+	CommitTransaction() (mcp.Tool, server.ToolHandlerFunc)
+	// AbortTransaction abort a started transaction
+	AbortTransaction() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type transactionTool struct{}
+
+func NewTransactionTool() TransactionTool {
+	return &transactionTool{}
+}
+
+// transaction is a server-side session with an open transaction, owned by one MCP session
+type transaction struct {
+	mu         sync.Mutex
+	id         string
+	mcpSession string
+	session    mongo.Session
+	timer      *time.Timer
+}
+
+type transactionRegistry struct {
+	mu           sync.Mutex
+	transactions map[string]*transaction
+}
+
+var transactions = &transactionRegistry{transactions: map[string]*transaction{}}
+
+// start opens a server-side session and starts a transaction which is aborted after timeout
+func (r *transactionRegistry) start(ctx context.Context, timeout time.Duration) (*transaction, error) {
+	session, err := client.MongoClient.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	if err = session.StartTransaction(); err != nil {
+		session.EndSession(ctx)
+		return nil, err
+	}
+
+	txn := &transaction{
+		id:         primitive.NewObjectID().Hex(),
+		mcpSession: mcpSessionID(ctx),
+		session:    session,
+	}
+	txn.timer = time.AfterFunc(timeout, func() {
+		if err := r.finish(context.Background(), txn.id, false); err == nil {
+			log.Printf("Transaction %s aborted after timeout %s", txn.id, timeout)
+		}
+	})
+
+	r.mu.Lock()
+	r.transactions[txn.id] = txn
+	r.mu.Unlock()
+	return txn, nil
+}
+
+// get returns the transaction if it belongs to the MCP session of ctx
+func (r *transactionRegistry) get(ctx context.Context, transactionID string) (*transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	txn, ok := r.transactions[transactionID]
+	if !ok || txn.mcpSession != mcpSessionID(ctx) {
+		return nil, fmt.Errorf("transaction %s not found, it may have been committed, aborted or timed out", transactionID)
+	}
+	return txn, nil
+}
+
+// finish removes the transaction from the registry, then commits or aborts it
+func (r *transactionRegistry) finish(ctx context.Context, transactionID string, commit bool) error {
+	r.mu.Lock()
+	txn, ok := r.transactions[transactionID]
+	if ok {
+		delete(r.transactions, transactionID)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("transaction %s not found, it may have been committed, aborted or timed out", transactionID)
+	}
+
+	txn.timer.Stop()
+	// wait for running operations of the transaction
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	defer txn.session.EndSession(context.Background())
+
+	if commit {
+		return txn.session.CommitTransaction(ctx)
+	}
+	return txn.session.AbortTransaction(ctx)
+}
+
+// AbortSessionTransactions aborts all transactions started by the given MCP session
+func AbortSessionTransactions(sessionID string) {
+	var ids []string
+	transactions.mu.Lock()
+	for id, txn := range transactions.transactions {
+		if txn.mcpSession == sessionID {
+			ids = append(ids, id)
+		}
+	}
+	transactions.mu.Unlock()
+
+	for _, id := range ids {
+		if err := transactions.finish(context.Background(), id, false); err != nil {
+			log.Printf("Abort transaction %s failed: %v", id, err)
+			continue
+		}
+		log.Printf("Transaction %s aborted, session %s disconnected", id, sessionID)
+	}
+}
+
+// transactionContext returns a context bound to the transaction, or ctx itself when transactionID is empty.
+// release must be called once the operation is done.
+func transactionContext(ctx context.Context, transactionID string) (context.Context, func(), error) {
+	if transactionID == "" {
+		return ctx, func() {}, nil
+	}
+	txn, err := transactions.get(ctx, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	txn.mu.Lock()
+	return mongo.NewSessionContext(ctx, txn.session), txn.mu.Unlock, nil
+}
+
+// mcpSessionID returns the id of the MCP client session of ctx
+func mcpSessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// StartTransaction start a multi-document transaction
+func (t transactionTool) StartTransaction() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"StartTransaction",
+		mcp.WithDescription("Start a multi-document transaction, pass the returned transaction_id to document tools "+
+			"and finish it with CommitTransaction or AbortTransaction. Requires a replica set or sharded cluster"),
+		mcp.WithNumber("timeout_seconds",
+			mcp.Description("Abort the transaction automatically if it is not committed within this time, "+
+				"at most 60 seconds, the default transaction lifetime limit of the server"),
+			mcp.DefaultNumber(defaultTransactionTimeout.Seconds()),
+			mcp.Min(1),
+			mcp.Max(maxTransactionTimeout.Seconds()),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.StartTransactionRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		timeout := defaultTransactionTimeout
+		if req.TimeoutSeconds > 0 {
+			timeout = min(time.Duration(req.TimeoutSeconds)*time.Second, maxTransactionTimeout)
+		}
+
+		txn, err := transactions.start(ctx, timeout)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		log.Printf("Transaction %s started, timeout: %s", txn.id, timeout)
+		return mcp.NewToolResultText(
+			fmt.Sprintf("Transaction started, transaction_id: %s, timeout: %s", txn.id, timeout),
+		), nil
+	}
+	return
+}
+
+// CommitTransaction commit a started transaction
+func (t transactionTool) CommitTransaction() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"CommitTransaction",
+		mcp.WithDescription("Commit a transaction started by StartTransaction"),
+		mcp.WithString("transaction_id",
+			mcp.Required(),
+			mcp.Description("Transaction id returned by StartTransaction"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.TransactionRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if _, err = transactions.get(ctx, req.TransactionID); err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		if err = transactions.finish(ctx, req.TransactionID, true); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		log.Printf("Transaction %s committed", req.TransactionID)
+		return mcp.NewToolResultText(fmt.Sprintf("Transaction %s committed", req.TransactionID)), nil
+	}
+	return
+}
+
+// AbortTransaction abort a started transaction
+func (t transactionTool) AbortTransaction() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"AbortTransaction",
+		mcp.WithDescription("Abort a transaction started by StartTransaction and discard its writes"),
+		mcp.WithString("transaction_id",
+			mcp.Required(),
+			mcp.Description("Transaction id returned by StartTransaction"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.TransactionRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if _, err = transactions.get(ctx, req.TransactionID); err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		if err = transactions.finish(ctx, req.TransactionID, false); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		log.Printf("Transaction %s aborted", req.TransactionID)
+		return mcp.NewToolResultText(fmt.Sprintf("Transaction %s aborted", req.TransactionID)), nil
+	}
+	return
+}
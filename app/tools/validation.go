@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+)
+
+type ValidationTool interface {
+	// GetValidator get the validator of a collection
+	GetValidator() (mcp.Tool, server.ToolHandlerFunc)
+	// SetValidator set or replace the validator of a collection
+	SetValidator() (mcp.Tool, server.ToolHandlerFunc)
+	// ValidateDocuments check existing documents against a proposed validator
+	ValidateDocuments() (mcp.Tool, server.ToolHandlerFunc)
+	// InferValidator infer a $jsonSchema validator from sampled documents
+	InferValidator() (mcp.Tool, server.ToolHandlerFunc)
+}
+
+type validationTool struct{}
+
+func NewValidationTool() ValidationTool {
+	return &validationTool{}
+}
+
+// GetValidator get the validator of a collection
+func (v validationTool) GetValidator() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"GetValidator",
+		mcp.WithDescription("Get the validator ($jsonSchema or query operators) of a collection with its validation level and action"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.GetValidatorRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		info, err := getCollectionInfo(ctx, req.Collection)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(info.Options.Validator) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("Collection %s has no validator", req.Collection)), nil
+		}
+		validator, err := bson.MarshalExtJSON(info.Options.Validator, false, false)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("validationLevel: %s, validationAction: %s\nvalidator: %s",
+			info.Options.ValidationLevel, info.Options.ValidationAction, validator)), nil
+	}
+	return
+}
+
+// SetValidator set or replace the validator of a collection
+func (v validationTool) SetValidator() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"SetValidator",
+		mcp.WithDescription("Set or replace the validator of a collection. Run ValidateDocuments first "+
+			"to find the existing documents the validator would reject"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("validator",
+			mcp.Required(),
+			mcp.Description("Validator as Extended JSON (e.g., {\"$jsonSchema\": {\"bsonType\": \"object\", \"required\": [\"name\"]}}), "+
+				"{} removes the validator"),
+		),
+		mcp.WithString("validation_level",
+			mcp.Description("strict validates all inserts and updates, moderate skips updates of documents already invalid, off disables validation. "+
+				"Unchanged when not given, strict for a collection without validator"),
+			mcp.Enum("strict", "moderate", "off"),
+		),
+		mcp.WithString("validation_action",
+			mcp.Description("error rejects invalid writes, warn only logs them. Unchanged when not given, error for a collection without validator"),
+			mcp.Enum("error", "warn"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.SetValidatorRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		validator, err := parseDocument(req.Validator)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+
+		log.Printf("Set validator of collection: %s, level: %q, action: %q", req.Collection, req.ValidationLevel, req.ValidationAction)
+
+		// the level and action are only changed when given, the current ones are kept otherwise
+		command := bson.D{
+			{Key: "collMod", Value: req.Collection},
+			{Key: "validator", Value: validator},
+		}
+		if req.ValidationLevel != "" {
+			command = append(command, bson.E{Key: "validationLevel", Value: req.ValidationLevel})
+		}
+		if req.ValidationAction != "" {
+			command = append(command, bson.E{Key: "validationAction", Value: req.ValidationAction})
+		}
+		if err = client.DB.RunCommand(ctx, command).Err(); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if len(validator) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("Validator of collection %s removed", req.Collection)), nil
+		}
+		info, err := getCollectionInfo(ctx, req.Collection)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("Validator of collection %s set", req.Collection)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Validator of collection %s set, validationLevel: %s, validationAction: %s",
+			req.Collection, info.Options.ValidationLevel, info.Options.ValidationAction)), nil
+	}
+	return
+}
+
+// ValidateDocuments check existing documents against a proposed validator
+func (v validationTool) ValidateDocuments() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"ValidateDocuments",
+		mcp.WithDescription("Count the existing documents of a collection violating a proposed validator and return a sample of them, "+
+			"before applying it with SetValidator"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithString("validator",
+			mcp.Required(),
+			mcp.Description("Proposed validator as Extended JSON (e.g., {\"$jsonSchema\": {\"bsonType\": \"object\", \"required\": [\"name\"]}})"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("Only check the documents matching this MongoDB query filter"),
+		),
+		mcp.WithNumber("sample_size",
+			mcp.Description("Number of violating documents to return"),
+			mcp.DefaultNumber(10),
+			mcp.Min(0),
+			mcp.Max(100),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.ValidateDocumentsRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if _, ok := request.Params.Arguments["sample_size"]; !ok {
+			req.SampleSize = 10
+		}
+
+		validator, err := parseDocument(req.Validator)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if len(validator) == 0 {
+			return mcp.NewToolResultText("validator is empty, every document is valid"), nil
+		}
+		if req.Filter == nil {
+			req.Filter = map[string]interface{}{}
+		}
+
+		log.Printf("Validate documents of collection: %s against validator: %s", req.Collection, req.Validator)
+
+		// a validator is a query filter, the documents it doesn't match are the violating ones
+		coll := client.DB.Collection(req.Collection)
+		violating := bson.D{{Key: "$and", Value: bson.A{req.Filter, bson.D{{Key: "$nor", Value: bson.A{validator}}}}}}
+		total, err := coll.CountDocuments(ctx, req.Filter)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		count, err := coll.CountDocuments(ctx, violating)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if count == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("All %d documents are valid", total)), nil
+		}
+
+		result := fmt.Sprintf("%d of %d documents violate the validator (%.1f%%)", count, total, float64(count)/float64(total)*100)
+		if req.SampleSize == 0 {
+			return mcp.NewToolResultText(result), nil
+		}
+		cur, err := coll.Find(ctx, violating, options.Find().SetLimit(req.SampleSize))
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		var documents []bson.D
+		if err = cur.All(ctx, &documents); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		sample, err := formatDocuments(documents)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s, sample:\n%s", result, sample)), nil
+	}
+	return
+}
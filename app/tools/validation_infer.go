@@ -0,0 +1,248 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"sort"
+)
+
+// bsonTypeAliases are the $jsonSchema names of the BSON types
+var bsonTypeAliases = map[bsontype.Type]string{
+	bsontype.Double:           "double",
+	bsontype.String:           "string",
+	bsontype.EmbeddedDocument: "object",
+	bsontype.Array:            "array",
+	bsontype.Binary:           "binData",
+	bsontype.Undefined:        "undefined",
+	bsontype.ObjectID:         "objectId",
+	bsontype.Boolean:          "bool",
+	bsontype.DateTime:         "date",
+	bsontype.Null:             "null",
+	bsontype.Regex:            "regex",
+	bsontype.DBPointer:        "dbPointer",
+	bsontype.JavaScript:       "javascript",
+	bsontype.Symbol:           "symbol",
+	bsontype.CodeWithScope:    "javascriptWithScope",
+	bsontype.Int32:            "int",
+	bsontype.Timestamp:        "timestamp",
+	bsontype.Int64:            "long",
+	bsontype.Decimal128:       "decimal",
+	bsontype.MinKey:           "minKey",
+	bsontype.MaxKey:           "maxKey",
+}
+
+// numberTypes are merged into the number alias when a field holds several of them
+var numberTypes = map[string]bool{"double": true, "int": true, "long": true, "decimal": true}
+
+// schemaNode accumulates the values seen at a path of the sampled documents
+type schemaNode struct {
+	count      int
+	types      map[string]int
+	strings    map[string]bool
+	manyValues bool
+	objects    int
+	fields     []string
+	properties map[string]*schemaNode
+	items      *schemaNode
+}
+
+func newSchemaNode() *schemaNode {
+	return &schemaNode{types: map[string]int{}, strings: map[string]bool{}, properties: map[string]*schemaNode{}}
+}
+
+// observe adds a value to the node, keeping at most enumMax distinct strings
+func (n *schemaNode) observe(value bson.RawValue, enumMax int) {
+	n.count++
+	n.types[bsonTypeAliases[value.Type]]++
+	switch value.Type {
+	case bsontype.String:
+		if !n.manyValues {
+			n.strings[value.StringValue()] = true
+			n.manyValues = len(n.strings) > enumMax
+		}
+	case bsontype.EmbeddedDocument:
+		n.observeDocument(value.Document(), enumMax)
+	case bsontype.Array:
+		values, _ := value.Array().Values()
+		for _, item := range values {
+			if n.items == nil {
+				n.items = newSchemaNode()
+			}
+			n.items.observe(item, enumMax)
+		}
+	}
+}
+
+// observeDocument adds the fields of a document to the node, in the order they first appear
+func (n *schemaNode) observeDocument(doc bson.Raw, enumMax int) {
+	n.objects++
+	elements, _ := doc.Elements()
+	for _, element := range elements {
+		key := element.Key()
+		child, ok := n.properties[key]
+		if !ok {
+			child = newSchemaNode()
+			n.properties[key] = child
+			n.fields = append(n.fields, key)
+		}
+		child.observe(element.Value(), enumMax)
+	}
+}
+
+// schema renders the $jsonSchema of the node, a field is required when present in requiredRatio of the objects
+func (n *schemaNode) schema(requiredRatio float64) bson.D {
+	var types []string
+	numbers := 0
+	for t := range n.types {
+		if numberTypes[t] {
+			numbers++
+		}
+	}
+	for t := range n.types {
+		if numbers > 1 && numberTypes[t] {
+			continue
+		}
+		types = append(types, t)
+	}
+	if numbers > 1 {
+		types = append(types, "number")
+	}
+	sort.Strings(types)
+
+	var schema bson.D
+	if len(types) == 1 {
+		schema = append(schema, bson.E{Key: "bsonType", Value: types[0]})
+	} else {
+		schema = append(schema, bson.E{Key: "bsonType", Value: types})
+	}
+
+	// only strings repeating in the sample are an enum, not unique values like names
+	if len(types) == 1 && types[0] == "string" && !n.manyValues && len(n.strings)*2 <= n.count {
+		var values []string
+		for value := range n.strings {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		schema = append(schema, bson.E{Key: "enum", Value: values})
+	}
+
+	if n.objects > 0 {
+		var required []string
+		properties := bson.D{}
+		for _, field := range n.fields {
+			child := n.properties[field]
+			if float64(child.count) >= requiredRatio*float64(n.objects) {
+				required = append(required, field)
+			}
+			properties = append(properties, bson.E{Key: field, Value: child.schema(requiredRatio)})
+		}
+		if len(required) > 0 {
+			schema = append(schema, bson.E{Key: "required", Value: required})
+		}
+		schema = append(schema, bson.E{Key: "properties", Value: properties})
+	}
+	if n.items != nil {
+		schema = append(schema, bson.E{Key: "items", Value: n.items.schema(requiredRatio)})
+	}
+	return schema
+}
+
+// InferValidator infer a $jsonSchema validator from sampled documents
+func (v validationTool) InferValidator() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"InferValidator",
+		mcp.WithDescription("Infer a $jsonSchema validator from sampled documents, with bsonType, required fields, "+
+			"enums of low-cardinality strings and nested object and array rules. Returns it ready for SetValidator "+
+			"with the percentage of current documents it accepts"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name"),
+		),
+		mcp.WithNumber("sample_size",
+			mcp.Description("Number of documents to sample"),
+			mcp.DefaultNumber(1000),
+			mcp.Min(1),
+			mcp.Max(100000),
+		),
+		mcp.WithNumber("required_ratio",
+			mcp.Description("Share of the sampled documents a field must be present in to be required, 1 requires it in all of them"),
+			mcp.DefaultNumber(1),
+			mcp.Min(0),
+			mcp.Max(1),
+		),
+		mcp.WithNumber("enum_max_values",
+			mcp.Description("Maximum number of distinct values of a string field to restrict it to an enum, 0 disables enums"),
+			mcp.DefaultNumber(10),
+			mcp.Min(0),
+			mcp.Max(100),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.InferValidatorRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.SampleSize <= 0 {
+			req.SampleSize = 1000
+		}
+		if _, ok := request.Params.Arguments["required_ratio"]; !ok {
+			req.RequiredRatio = 1
+		}
+		if _, ok := request.Params.Arguments["enum_max_values"]; !ok {
+			req.EnumMaxValues = 10
+		}
+
+		log.Printf("Infer validator of collection: %s from %d documents", req.Collection, req.SampleSize)
+
+		coll := client.DB.Collection(req.Collection)
+		cur, err := coll.Aggregate(ctx, mongo.Pipeline{{{Key: "$sample", Value: bson.M{"size": req.SampleSize}}}})
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cur.Close(ctx)
+
+		root := newSchemaNode()
+		for cur.Next(ctx) {
+			root.observeDocument(cur.Current, req.EnumMaxValues)
+		}
+		if err = cur.Err(); err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		if root.objects == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("Collection %s has no documents to infer a validator from", req.Collection)), nil
+		}
+
+		root.types = map[string]int{"object": root.objects}
+		validator := bson.D{{Key: "$jsonSchema", Value: root.schema(req.RequiredRatio)}}
+		data, err := bson.MarshalExtJSON(validator, false, false)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		total, err := coll.CountDocuments(ctx, bson.D{})
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		accepted, err := coll.CountDocuments(ctx, validator)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Inferred from %d sampled documents, accepts %d of %d documents (%.1f%%), "+
+			"review it before SetValidator, use ValidateDocuments to see the rejected ones\nvalidator: %s",
+			root.objects, accepted, total, float64(accepted)/float64(total)*100, data)), nil
+	}
+	return
+}
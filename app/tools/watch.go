@@ -0,0 +1,374 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"mcp/app/client"
+	"mcp/app/model"
+	"sync"
+	"time"
+)
+
+const (
+	watchResourcePrefix = "mongodb://watch/"
+	// maxBufferedEvents is the number of events a subscription keeps until its resource is read
+	maxBufferedEvents = 1000
+)
+
+type WatchTool interface {
+	// Watch collect change events of a collection or database
+	Watch() (mcp.Tool, server.ToolHandlerFunc)
+	// WatchSubscribe subscribe to change events of a collection
+	WatchSubscribe() (mcp.Tool, server.ToolHandlerFunc)
+	// WatchUnsubscribe stop a subscription of a collection
+	WatchUnsubscribe() (mcp.Tool, server.ToolHandlerFunc)
+	// WatchResource read change events buffered by a subscription
+	WatchResource() (mcp.ResourceTemplate, server.ResourceTemplateHandlerFunc)
+}
+
+type watchTool struct{}
+
+func NewWatchTool() WatchTool {
+	return &watchTool{}
+}
+
+// watchSubscription is a change stream running in background for one MCP session
+type watchSubscription struct {
+	mu          sync.Mutex
+	uri         string
+	events      []bson.Raw
+	dropped     int
+	resumeToken bson.Raw
+	err         error
+	cancel      context.CancelFunc
+}
+
+type watchRegistry struct {
+	mu sync.Mutex
+	// subscriptions is keyed by MCP session id, then by resource uri
+	subscriptions map[string]map[string]*watchSubscription
+}
+
+var watches = &watchRegistry{subscriptions: map[string]map[string]*watchSubscription{}}
+
+func (r *watchRegistry) add(sessionID string, sub *watchSubscription) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.subscriptions[sessionID] == nil {
+		r.subscriptions[sessionID] = map[string]*watchSubscription{}
+	}
+	if old, ok := r.subscriptions[sessionID][sub.uri]; ok {
+		old.cancel()
+	}
+	r.subscriptions[sessionID][sub.uri] = sub
+}
+
+func (r *watchRegistry) get(sessionID string, uri string) (*watchSubscription, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subscriptions[sessionID][uri]
+	return sub, ok
+}
+
+func (r *watchRegistry) remove(sessionID string, uri string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subscriptions[sessionID][uri]
+	if ok {
+		sub.cancel()
+		delete(r.subscriptions[sessionID], uri)
+	}
+	return ok
+}
+
+// CloseSessionWatches stops all subscriptions of the given MCP session
+func CloseSessionWatches(sessionID string) {
+	watches.mu.Lock()
+	defer watches.mu.Unlock()
+	for _, sub := range watches.subscriptions[sessionID] {
+		sub.cancel()
+	}
+	delete(watches.subscriptions, sessionID)
+}
+
+// push buffers an event, dropping the oldest one when the buffer is full
+func (s *watchSubscription) push(event bson.Raw, resumeToken bson.Raw) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) >= maxBufferedEvents {
+		s.events = s.events[1:]
+		s.dropped++
+	}
+	s.events = append(s.events, event)
+	s.resumeToken = resumeToken
+}
+
+// drain returns the buffered events and empties the buffer
+func (s *watchSubscription) drain() (bson.D, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := bson.D{
+		{Key: "events", Value: append([]bson.Raw{}, s.events...)},
+		{Key: "dropped", Value: s.dropped},
+		{Key: "resume_token", Value: s.resumeToken},
+	}
+	s.events = nil
+	s.dropped = 0
+	return result, s.err
+}
+
+// openChangeStream watches the collection, or the whole database when collection is empty
+func openChangeStream(ctx context.Context, collection string, pipeline string, fullDocument bool, resumeAfter string) (*mongo.ChangeStream, error) {
+	stages, err := parsePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.ChangeStream()
+	if fullDocument {
+		opts.SetFullDocument(options.UpdateLookup)
+	}
+	if resumeAfter != "" {
+		token, err := parseDocument(resumeAfter)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetResumeAfter(token)
+	}
+	if collection == "" {
+		return client.DB.Watch(ctx, stages, opts)
+	}
+	return client.DB.Collection(collection).Watch(ctx, stages, opts)
+}
+
+// Watch collect change events of a collection or database
+func (w watchTool) Watch() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"Watch",
+		mcp.WithDescription("Open a change stream and collect change events for a bounded duration or count. "+
+			"Requires a replica set or sharded cluster"),
+		mcp.WithString("collection",
+			mcp.Description("Collection name to watch, watch the whole database if empty"),
+		),
+		mcp.WithString("pipeline",
+			mcp.Description("Aggregation pipeline to filter events as an Extended JSON array "+
+				"(e.g., [{\"$match\": {\"operationType\": \"update\"}}])"),
+		),
+		mcp.WithBoolean("full_document",
+			mcp.Description("Include the current version of updated documents"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("resume_after",
+			mcp.Description("Resume token returned by a previous call, as Extended JSON"),
+		),
+		mcp.WithNumber("max_events",
+			mcp.Description("Stop after this number of events"),
+			mcp.DefaultNumber(10),
+			mcp.Min(1),
+			mcp.Max(1000),
+		),
+		mcp.WithNumber("duration_seconds",
+			mcp.Description("Stop after this number of seconds"),
+			mcp.DefaultNumber(10),
+			mcp.Min(1),
+			mcp.Max(300),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.WatchRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		if req.MaxEvents <= 0 {
+			req.MaxEvents = 10
+		}
+		if req.DurationSeconds <= 0 {
+			req.DurationSeconds = 10
+		}
+
+		log.Printf("Watch collection: %s, pipeline: %s, duration: %ds", req.Collection, req.Pipeline, req.DurationSeconds)
+
+		cs, err := openChangeStream(ctx, req.Collection, req.Pipeline, req.FullDocument, req.ResumeAfter)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		defer cs.Close(context.Background())
+
+		watchCtx, cancel := context.WithTimeout(ctx, time.Duration(req.DurationSeconds)*time.Second)
+		defer cancel()
+
+		var events []bson.Raw
+		for len(events) < req.MaxEvents && cs.Next(watchCtx) {
+			events = append(events, append(bson.Raw{}, cs.Current...))
+		}
+		if err = cs.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		result, err := formatDocuments(events)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), err
+		}
+		text := fmt.Sprintf("Collected %d events\n%s", len(events), result)
+		if cs.ResumeToken() != nil {
+			token, err := bson.MarshalExtJSON(cs.ResumeToken(), false, false)
+			if err != nil {
+				return mcp.NewToolResultText(err.Error()), err
+			}
+			text += fmt.Sprintf("resume_token: %s", token)
+		}
+		return mcp.NewToolResultText(text), nil
+	}
+	return
+}
+
+// WatchSubscribe subscribe to change events of a collection
+func (w watchTool) WatchSubscribe() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"WatchSubscribe",
+		mcp.WithDescription("Subscribe to change events of a collection. Events are buffered in the resource "+
+			watchResourcePrefix+"{collection} and a resources/updated notification is sent for each of them"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name to watch"),
+		),
+		mcp.WithString("pipeline",
+			mcp.Description("Aggregation pipeline to filter events as an Extended JSON array"),
+		),
+		mcp.WithBoolean("full_document",
+			mcp.Description("Include the current version of updated documents"),
+			mcp.DefaultBool(false),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.WatchSubscribeRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return mcp.NewToolResultText("Subscription requires a client session"), nil
+		}
+
+		// the change stream outlives the tool call, it is stopped by WatchUnsubscribe or on disconnect
+		watchCtx, cancel := context.WithCancel(context.Background())
+		cs, err := openChangeStream(watchCtx, req.Collection, req.Pipeline, req.FullDocument, "")
+		if err != nil {
+			cancel()
+			return mcp.NewToolResultText(err.Error()), err
+		}
+
+		sub := &watchSubscription{uri: watchResourcePrefix + req.Collection, cancel: cancel}
+		watches.add(session.SessionID(), sub)
+		log.Printf("Session %s subscribed to %s", session.SessionID(), sub.uri)
+
+		go func() {
+			defer cs.Close(context.Background())
+			for cs.Next(watchCtx) {
+				sub.push(append(bson.Raw{}, cs.Current...), cs.ResumeToken())
+				notification := mcp.JSONRPCNotification{
+					JSONRPC: mcp.JSONRPC_VERSION,
+					Notification: mcp.Notification{
+						Method: "notifications/resources/updated",
+						Params: mcp.NotificationParams{
+							AdditionalFields: map[string]interface{}{"uri": sub.uri},
+						},
+					},
+				}
+				select {
+				case session.NotificationChannel() <- notification:
+				default:
+					// client is not consuming notifications, events stay buffered
+				}
+			}
+			if err := cs.Err(); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Subscription %s stopped: %v", sub.uri, err)
+				sub.mu.Lock()
+				sub.err = err
+				sub.mu.Unlock()
+			}
+		}()
+
+		return mcp.NewToolResultText(fmt.Sprintf("Subscribed, read events from resource %s", sub.uri)), nil
+	}
+	return
+}
+
+// WatchUnsubscribe stop a subscription of a collection
+func (w watchTool) WatchUnsubscribe() (tool mcp.Tool, handler server.ToolHandlerFunc) {
+
+	// MCP Tool
+	tool = mcp.NewTool(
+		"WatchUnsubscribe",
+		mcp.WithDescription("Stop a subscription started by WatchSubscribe"),
+		mcp.WithString("collection",
+			mcp.Required(),
+			mcp.Description("Collection name of the subscription"),
+		),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var req model.WatchUnsubscribeRequest
+		err := mapstructure.Decode(request.Params.Arguments, &req)
+		if err != nil {
+			return mcp.NewToolResultText("Parse request failed"), err
+		}
+
+		uri := watchResourcePrefix + req.Collection
+		if !watches.remove(mcpSessionID(ctx), uri) {
+			return mcp.NewToolResultText(fmt.Sprintf("No subscription found for %s", uri)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Unsubscribed from %s", uri)), nil
+	}
+	return
+}
+
+// WatchResource read change events buffered by a subscription
+func (w watchTool) WatchResource() (template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) {
+
+	// MCP Resource Template
+	template = mcp.NewResourceTemplate(
+		watchResourcePrefix+"{collection}",
+		"Collection change events",
+		mcp.WithTemplateDescription("Change events buffered since the last read, subscribe first with WatchSubscribe"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	// handler
+	handler = func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		sub, ok := watches.get(mcpSessionID(ctx), request.Params.URI)
+		if !ok {
+			return nil, fmt.Errorf("not subscribed to %s, call WatchSubscribe first", request.Params.URI)
+		}
+		result, err := sub.drain()
+		if err != nil {
+			return nil, err
+		}
+		data, err := bson.MarshalExtJSON(result, false, false)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	}
+	return
+}
@@ -22,9 +22,10 @@ func main() {
 		server.WithResourceCapabilities(true, true),
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithHooks(app.NewHooks()),
 	)
 	// 添加工具到 MCP 服务器中
-	app.AddTools(s)
+	app.AddTools(s, config)
 	// Start the server
 	if MCPConfig.SSE {
 		// SSE server